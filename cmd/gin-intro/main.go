@@ -0,0 +1,23 @@
+// Command gin-intro runs the server and talks to a running one:
+//
+//	gin-intro serve [config flags]
+//	gin-intro greet [flags] <name>
+//	gin-intro person get [flags] [id]
+//	gin-intro person import [flags] <file>
+//	gin-intro routes [flags] [config flags]
+//	gin-intro config validate [config flags]
+//
+// Client commands find the server through the same configuration file and
+// GIN_INTRO_* environment that serve reads, or through -url.
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/cli"
+)
+
+func main() {
+	os.Exit(cli.Main(filepath.Base(os.Args[0]), os.Args[1:]))
+}
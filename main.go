@@ -1,17 +1,11 @@
 package main
 
 import (
-	"github.com/faishalshidqi/gin-introductory-proj/src/handlers"
-	"github.com/gin-gonic/gin"
+	"os"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/cli"
 )
 
 func main() {
-	router := gin.Default()
-	router.GET(
-		"/:name", handlers.IndexHandler,
-	)
-	router.GET(
-		"/person", handlers.PersonHandler,
-	)
-	router.Run(":9000")
+	os.Exit(cli.Serve(os.Args[0], os.Args[1:]))
 }
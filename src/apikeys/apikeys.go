@@ -0,0 +1,339 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/auth"
+	"golang.org/x/crypto/sha3"
+)
+
+// tokenPrefix marks plaintext keys so they are recognisable in logs and
+// secret scanners. A full key reads "gip_<id>_<secret>".
+const tokenPrefix = "gip_"
+
+var (
+	ErrNotFound     = errors.New("api key not found")
+	ErrInvalidKey   = errors.New("invalid api key")
+	ErrRevoked      = errors.New("api key revoked")
+	ErrExpired      = errors.New("api key expired")
+	ErrInvalidScope = errors.New("scopes must look like resource:action")
+)
+
+var scopePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*:[a-z][a-z0-9-]*$`)
+
+// Key describes an API key. The plaintext is never stored; only its
+// SHA3-256 hash is kept, in storedKey.
+type Key struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"createdBy,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	RotatedAt  *time.Time `json:"rotatedAt,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// storedKey adds the hash, which is persisted but never part of API
+// responses.
+type storedKey struct {
+	Key
+	Hash string `json:"hash"`
+}
+
+func hashToken(token string) string {
+	sum := sha3.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newToken(id string) string {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return tokenPrefix + id + "_" + base64.RawURLEncoding.EncodeToString(secret)
+}
+
+func newKeyID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// parseToken extracts the key ID so lookups need not scan every hash.
+func parseToken(token string) (string, bool) {
+	rest, ok := strings.CutPrefix(token, tokenPrefix)
+	if !ok {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	return id, ok && id != "" && secret != ""
+}
+
+// Store keeps API keys in memory and, when given a path, mirrors every
+// change to a JSON file. To keep authentication off the disk, last-used
+// times are written out every flush interval, alongside other changes
+// and on Close.
+type Store struct {
+	mu    sync.RWMutex
+	path  string
+	keys  map[string]*storedKey
+	now   func() time.Time
+	dirty bool
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewStore loads the keys at path, or keeps them in memory only when path
+// is empty. A positive flushInterval persists last-used times that often.
+func NewStore(path string, flushInterval time.Duration) (*Store, error) {
+	s := &Store{path: path, keys: make(map[string]*storedKey), now: time.Now, stop: make(chan struct{})}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		var keys []*storedKey
+		if err := json.Unmarshal(data, &keys); err != nil {
+			return nil, fmt.Errorf("apikeys: %s: %w", path, err)
+		}
+		for _, k := range keys {
+			s.keys[k.ID] = k
+		}
+	}
+	if flushInterval > 0 {
+		go s.flushEvery(flushInterval)
+	}
+	return s, nil
+}
+
+func (s *Store) flushEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			var err error
+			if s.dirty {
+				err = s.persist()
+			}
+			s.mu.Unlock()
+			if err != nil {
+				slog.Warn("apikeys: persisting last-used times", "error", err)
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Mint creates a key and returns it together with its plaintext, which is
+// not recoverable afterwards.
+func (s *Store) Mint(name string, scopes []string, expiresAt *time.Time, createdBy string) (Key, string, error) {
+	for _, scope := range scopes {
+		if !scopePattern.MatchString(scope) {
+			return Key{}, "", fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := newKeyID()
+	token := newToken(id)
+	k := &storedKey{Key: Key{
+		ID:        id,
+		Name:      name,
+		Scopes:    append([]string(nil), scopes...),
+		CreatedBy: createdBy,
+		CreatedAt: s.now().UTC(),
+		ExpiresAt: expiresAt,
+	}}
+	k.Hash = hashToken(token)
+	s.keys[id] = k
+	if err := s.persist(); err != nil {
+		delete(s.keys, id)
+		return Key{}, "", err
+	}
+	return k.Key, token, nil
+}
+
+func (s *Store) List() []Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k.Key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys
+}
+
+func (s *Store) Get(id string) (Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok := s.keys[id]
+	if !ok {
+		return Key{}, ErrNotFound
+	}
+	return k.Key, nil
+}
+
+// Rotate replaces the secret of a live key; the old plaintext stops working
+// immediately.
+func (s *Store) Rotate(id string) (Key, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[id]
+	if !ok {
+		return Key{}, "", ErrNotFound
+	}
+	if k.RevokedAt != nil {
+		return Key{}, "", ErrRevoked
+	}
+	previous := *k
+	token := newToken(id)
+	now := s.now().UTC()
+	k.Hash = hashToken(token)
+	k.RotatedAt = &now
+	if err := s.persist(); err != nil {
+		*k = previous
+		return Key{}, "", err
+	}
+	return k.Key, token, nil
+}
+
+func (s *Store) Revoke(id string) (Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[id]
+	if !ok {
+		return Key{}, ErrNotFound
+	}
+	if k.RevokedAt == nil {
+		now := s.now().UTC()
+		k.RevokedAt = &now
+		if err := s.persist(); err != nil {
+			k.RevokedAt = nil
+			return Key{}, err
+		}
+	}
+	return k.Key, nil
+}
+
+// Verify checks a plaintext key and records its use.
+func (s *Store) Verify(token string) (Key, error) {
+	id, ok := parseToken(token)
+	if !ok {
+		return Key{}, ErrInvalidKey
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[id]
+	if !ok || subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hashToken(token))) != 1 {
+		return Key{}, ErrInvalidKey
+	}
+	now := s.now().UTC()
+	if k.RevokedAt != nil {
+		return Key{}, ErrRevoked
+	}
+	if k.ExpiresAt != nil && !now.Before(*k.ExpiresAt) {
+		return Key{}, ErrExpired
+	}
+	k.LastUsedAt = &now
+	s.dirty = s.path != ""
+	return k.Key, nil
+}
+
+// AuthenticateKey implements auth.KeyAuthenticator.
+func (s *Store) AuthenticateKey(ctx context.Context, token string) (*auth.Principal, error) {
+	k, err := s.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.Principal{
+		Subject: "apikey:" + k.ID,
+		Method:  auth.MethodAPIKey,
+		Scopes:  k.Scopes,
+	}, nil
+}
+
+func (s *Store) persist() error {
+	if s.path == "" {
+		return nil
+	}
+	keys := make([]*storedKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	// The rename is only durable once the directory entry is.
+	dir, err := os.Open(filepath.Dir(s.path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	if err := dir.Sync(); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// Close stops the periodic flush and writes out pending last-used times.
+func (s *Store) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.persist()
+}
@@ -0,0 +1,205 @@
+package apikeys
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestStore(t *testing.T, path string) *Store {
+	t.Helper()
+	s, err := NewStore(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = func() time.Time { return testNow }
+	return s
+}
+
+func TestVerify(t *testing.T) {
+	s := newTestStore(t, "")
+	defer s.Close()
+	mint := func(expiresAt *time.Time) (Key, string) {
+		k, token, err := s.Mint("batch", []string{"persons:read"}, expiresAt, "ada")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k, token
+	}
+	past, future := testNow.Add(-time.Second), testNow.Add(time.Hour)
+
+	valid, validToken := mint(nil)
+	_, expiringToken := mint(&future)
+	_, expiredToken := mint(&past)
+	revoked, revokedToken := mint(nil)
+	if _, err := s.Revoke(revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+	rotated, oldToken := mint(nil)
+	_, newToken, err := s.Rotate(rotated.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := parseToken(validToken)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantID  string
+		wantErr error
+	}{
+		{"valid", validToken, valid.ID, nil},
+		{"not yet expired", expiringToken, "", nil},
+		{"rotated", newToken, rotated.ID, nil},
+		{"empty", "", "", ErrInvalidKey},
+		{"no prefix", strings.TrimPrefix(validToken, tokenPrefix), "", ErrInvalidKey},
+		{"no secret", tokenPrefix + id + "_", "", ErrInvalidKey},
+		{"unknown id", tokenPrefix + "000000000000_secret", "", ErrInvalidKey},
+		{"wrong secret", tokenPrefix + id + "_secret", "", ErrInvalidKey},
+		{"secret of another key", tokenPrefix + id + "_" + strings.SplitN(expiringToken, "_", 3)[2], "", ErrInvalidKey},
+		{"replaced by rotation", oldToken, "", ErrInvalidKey},
+		{"revoked", revokedToken, "", ErrRevoked},
+		{"expired", expiredToken, "", ErrExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := s.Verify(tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if tt.wantID != "" && k.ID != tt.wantID {
+				t.Errorf("key = %s, want %s", k.ID, tt.wantID)
+			}
+			if k.LastUsedAt == nil || !k.LastUsedAt.Equal(testNow) {
+				t.Errorf("LastUsedAt = %v, want %v", k.LastUsedAt, testNow)
+			}
+		})
+	}
+}
+
+func TestMintRejectsBadScopes(t *testing.T) {
+	s := newTestStore(t, "")
+	for _, scope := range []string{"", "persons", "Persons:read", "persons:read:own", "persons:", ":read"} {
+		if _, _, err := s.Mint("bad", []string{scope}, nil, ""); !errors.Is(err, ErrInvalidScope) {
+			t.Errorf("Mint with scope %q: error = %v, want %v", scope, err, ErrInvalidScope)
+		}
+	}
+	if n := len(s.List()); n != 0 {
+		t.Errorf("%d keys minted with bad scopes", n)
+	}
+}
+
+func TestRotateAndRevokeUnknownOrRevoked(t *testing.T) {
+	s := newTestStore(t, "")
+	if _, _, err := s.Rotate("nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Rotate unknown: %v", err)
+	}
+	if _, err := s.Revoke("nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Revoke unknown: %v", err)
+	}
+	k, _, err := s.Mint("batch", []string{"persons:read"}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := s.Revoke(k.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := s.Revoke(k.ID)
+	if err != nil || !again.RevokedAt.Equal(*first.RevokedAt) {
+		t.Errorf("revoking twice = %v, %v; want the first revocation kept", again.RevokedAt, err)
+	}
+	if _, _, err := s.Rotate(k.ID); !errors.Is(err, ErrRevoked) {
+		t.Errorf("Rotate revoked: %v, want %v", err, ErrRevoked)
+	}
+}
+
+func TestKeyFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	s := newTestStore(t, path)
+	expires := testNow.Add(24 * time.Hour)
+	used, usedToken, err := s.Mint("used", []string{"persons:read", "persons:create"}, &expires, "ada")
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, _, err := s.Mint("revoked", []string{"persons:read"}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Revoke(revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+	rotated, _, err := s.Mint("rotated", []string{"api-keys:manage"}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, rotatedToken, err := s.Rotate(rotated.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Last-used times are only written on flush or Close.
+	if _, err := s.Verify(usedToken); err != nil {
+		t.Fatal(err)
+	}
+	want := s.List()
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range []string{usedToken, rotatedToken} {
+		_, secret, _ := strings.Cut(strings.TrimPrefix(token, tokenPrefix), "_")
+		if strings.Contains(string(data), secret) {
+			t.Fatal("the key file holds a plaintext secret")
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("key file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+
+	s = newTestStore(t, path)
+	defer s.Close()
+	got := s.List()
+	if len(got) != len(want) {
+		t.Fatalf("reloaded %d keys, want %d", len(got), len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.ID != w.ID || g.Name != w.Name || strings.Join(g.Scopes, ",") != strings.Join(w.Scopes, ",") ||
+			g.CreatedBy != w.CreatedBy || !g.CreatedAt.Equal(w.CreatedAt) ||
+			!equalTime(g.ExpiresAt, w.ExpiresAt) || !equalTime(g.RotatedAt, w.RotatedAt) ||
+			!equalTime(g.RevokedAt, w.RevokedAt) || !equalTime(g.LastUsedAt, w.LastUsedAt) {
+			t.Errorf("key %d = %+v, want %+v", i, g, w)
+		}
+	}
+	if k, err := s.Get(used.ID); err != nil || k.LastUsedAt == nil {
+		t.Errorf("last use of %s was not persisted: %+v, %v", used.ID, k, err)
+	}
+	if _, err := s.Verify(usedToken); err != nil {
+		t.Errorf("Verify after reload: %v", err)
+	}
+	if _, err := s.Verify(rotatedToken); err != nil {
+		t.Errorf("Verify rotated key after reload: %v", err)
+	}
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
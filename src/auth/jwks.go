@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+// jwk is the subset of RFC 7517 fields needed for HS256, RS256 and EdDSA.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+}
+
+// Key is a parsed verification key. Exactly one of the key fields is set.
+type Key struct {
+	ID      string
+	Alg     string
+	hmac    []byte
+	rsa     *rsa.PublicKey
+	ed25519 ed25519.PublicKey
+}
+
+const minRSABits = 2048
+
+func parseJWKS(data []byte) ([]Key, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	keys := make([]Key, 0, len(set.Keys))
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key := Key{ID: k.Kid, Alg: k.Alg}
+		switch k.Kty {
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil || len(secret) < 32 {
+				return nil, fmt.Errorf("auth: jwks key %d: oct key must be at least 256 bits of base64url", i)
+			}
+			key.hmac = secret
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+				return nil, fmt.Errorf("auth: jwks key %d: malformed RSA key", i)
+			}
+			pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+			if pub.N.BitLen() < minRSABits {
+				return nil, fmt.Errorf("auth: jwks key %d: RSA key shorter than %d bits", i, minRSABits)
+			}
+			key.rsa = pub
+		case "OKP":
+			x, err := base64.RawURLEncoding.DecodeString(k.X)
+			if k.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("auth: jwks key %d: only Ed25519 OKP keys are supported", i)
+			}
+			key.ed25519 = ed25519.PublicKey(x)
+		default:
+			return nil, fmt.Errorf("auth: jwks key %d: unsupported kty %q", i, k.Kty)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// supports reports whether k may verify tokens signed with alg. The key
+// type is bound to the algorithm so an RSA public key can never be used as
+// an HMAC secret.
+func (k Key) supports(alg string) bool {
+	if k.Alg != "" && k.Alg != alg {
+		return false
+	}
+	switch alg {
+	case "HS256":
+		return k.hmac != nil
+	case "RS256":
+		return k.rsa != nil
+	case "EdDSA":
+		return k.ed25519 != nil
+	}
+	return false
+}
+
+// KeySet holds the keys of a local JWKS file and reloads them when the
+// file's modification time changes.
+type KeySet struct {
+	path string
+
+	mu      sync.RWMutex
+	keys    []Key
+	modTime time.Time
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// LoadKeySet reads path and, when interval is positive, polls it for
+// changes. A reload that fails keeps the previously loaded keys.
+func LoadKeySet(path string, interval time.Duration) (*KeySet, error) {
+	ks := &KeySet{path: path, stop: make(chan struct{})}
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+	if interval > 0 {
+		go ks.watch(interval)
+	}
+	return ks, nil
+}
+
+func (ks *KeySet) Reload() error {
+	info, err := os.Stat(ks.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(ks.path)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("auth: jwks contains no signing keys")
+	}
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.modTime = info.ModTime()
+	ks.mu.Unlock()
+	return nil
+}
+
+func (ks *KeySet) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			info, err := os.Stat(ks.path)
+			if err != nil {
+				slog.Warn("auth: stat jwks", "path", ks.path, "error", err)
+				continue
+			}
+			ks.mu.RLock()
+			changed := !info.ModTime().Equal(ks.modTime)
+			ks.mu.RUnlock()
+			if !changed {
+				continue
+			}
+			if err := ks.Reload(); err != nil {
+				slog.Warn("auth: reload jwks, keeping previous keys", "path", ks.path, "error", err)
+				continue
+			}
+			slog.Info("auth: reloaded jwks", "path", ks.path)
+		case <-ks.stop:
+			return
+		}
+	}
+}
+
+// candidates returns the keys that may verify a token with kid and alg.
+func (ks *KeySet) candidates(kid, alg string) []Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	var out []Key
+	for _, k := range ks.keys {
+		if kid != "" && k.ID != kid {
+			continue
+		}
+		if k.supports(alg) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func (ks *KeySet) Close() error {
+	ks.once.Do(func() { close(ks.stop) })
+	return nil
+}
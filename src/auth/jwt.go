@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrExpired          = errors.New("token expired")
+	ErrNotYetValid      = errors.New("token not yet valid")
+	ErrIssuer           = errors.New("unexpected issuer")
+	ErrAudience         = errors.New("unexpected audience")
+)
+
+// audience accepts both the string and array forms of the aud claim.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+type Claims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *int64   `json:"exp"`
+	NotBefore *int64   `json:"nbf"`
+	IssuedAt  *int64   `json:"iat"`
+	Roles     []string `json:"roles"`
+	Scope     string   `json:"scope"`
+}
+
+type VerifierOptions struct {
+	Issuer    string
+	Audience  string
+	ClockSkew time.Duration
+	Now       func() time.Time
+}
+
+// Verifier validates compact-serialized JWS tokens against a KeySet.
+type Verifier struct {
+	keys *KeySet
+	opts VerifierOptions
+}
+
+func NewVerifier(keys *KeySet, opts VerifierOptions) *Verifier {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Verifier{keys: keys, opts: opts}
+}
+
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+		Typ string `json:"typ"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, ErrMalformedToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	keys := v.keys.candidates(header.Kid, header.Alg)
+	if len(keys) == 0 {
+		if header.Alg != "HS256" && header.Alg != "RS256" && header.Alg != "EdDSA" {
+			return nil, ErrUnsupportedAlg
+		}
+		return nil, ErrInvalidSignature
+	}
+	verified := false
+	for _, k := range keys {
+		if verifySignature(k, header.Alg, signed, sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, ErrInvalidSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+	if err := v.validate(&claims); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+func verifySignature(k Key, alg string, signed, sig []byte) bool {
+	switch alg {
+	case "HS256":
+		mac := hmac.New(sha256.New, k.hmac)
+		mac.Write(signed)
+		return hmac.Equal(mac.Sum(nil), sig)
+	case "RS256":
+		digest := sha256.Sum256(signed)
+		return rsa.VerifyPKCS1v15(k.rsa, crypto.SHA256, digest[:], sig) == nil
+	case "EdDSA":
+		return ed25519.Verify(k.ed25519, signed, sig)
+	}
+	return false
+}
+
+func (v *Verifier) validate(c *Claims) error {
+	now := v.opts.Now()
+	skew := v.opts.ClockSkew
+	// exp is mandatory: a token that never expires cannot be revoked.
+	if c.ExpiresAt == nil || !now.Before(time.Unix(*c.ExpiresAt, 0).Add(skew)) {
+		return ErrExpired
+	}
+	if c.NotBefore != nil && now.Add(skew).Before(time.Unix(*c.NotBefore, 0)) {
+		return ErrNotYetValid
+	}
+	if v.opts.Issuer != "" && c.Issuer != v.opts.Issuer {
+		return ErrIssuer
+	}
+	if v.opts.Audience != "" {
+		found := false
+		for _, aud := range c.Audience {
+			if aud == v.opts.Audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ErrAudience
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testSecret = []byte("0123456789abcdef0123456789abcdef")
+	testNow    = time.Unix(1_700_000_000, 0)
+)
+
+// newTestVerifier verifies against an HMAC key, kid "hs" with testSecret,
+// and a fresh Ed25519 key, kid "ed", at testNow.
+func newTestVerifier(t *testing.T) (*Verifier, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "oct", "kid": "hs", "k": b64(testSecret)},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": b64(pub)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := LoadKeySet(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { keys.Close() })
+	return NewVerifier(keys, VerifierOptions{
+		Issuer:    "issuer",
+		Audience:  "audience",
+		ClockSkew: time.Minute,
+		Now:       func() time.Time { return testNow },
+	}), priv
+}
+
+// validClaims are accepted by newTestVerifier after edit, if any, changes
+// them.
+func validClaims(edit func(map[string]any)) map[string]any {
+	claims := map[string]any{
+		"iss": "issuer",
+		"sub": "ada",
+		"aud": "audience",
+		"exp": testNow.Add(time.Hour).Unix(),
+	}
+	if edit != nil {
+		edit(claims)
+	}
+	return claims
+}
+
+func hs(t *testing.T, header, claims map[string]any) string {
+	t.Helper()
+	return sign(t, header, claims, func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, testSecret)
+		mac.Write(signed)
+		return mac.Sum(nil)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	v, priv := newTestVerifier(t)
+	b64 := base64.RawURLEncoding.EncodeToString
+	ed := func(header, claims map[string]any) string {
+		return sign(t, header, claims, func(signed []byte) []byte {
+			return ed25519.Sign(priv, signed)
+		})
+	}
+	hsHeader := map[string]any{"alg": "HS256", "kid": "hs"}
+	valid := validClaims
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"HS256", hs(t, hsHeader, valid(nil)), nil},
+		{"EdDSA", ed(map[string]any{"alg": "EdDSA", "kid": "ed"}, valid(nil)), nil},
+		{"no kid tries every key", hs(t, map[string]any{"alg": "HS256"}, valid(nil)), nil},
+		{"audience array", hs(t, hsHeader, valid(func(c map[string]any) {
+			c["aud"] = []string{"other", "audience"}
+		})), nil},
+		{"expired within skew", hs(t, hsHeader, valid(func(c map[string]any) {
+			c["exp"] = testNow.Add(-30 * time.Second).Unix()
+		})), nil},
+		{"not before within skew", hs(t, hsHeader, valid(func(c map[string]any) {
+			c["nbf"] = testNow.Add(30 * time.Second).Unix()
+		})), nil},
+
+		{"two parts", "e30.e30", ErrMalformedToken},
+		{"header not base64", "!." + b64([]byte("{}")) + ".sig", ErrMalformedToken},
+		{"claims not JSON", hs(t, hsHeader, nil), ErrMalformedToken},
+		{"alg none", sign(t, map[string]any{"alg": "none"}, valid(nil), func([]byte) []byte { return nil }), ErrUnsupportedAlg},
+		{"HS512", hs(t, map[string]any{"alg": "HS512", "kid": "hs"}, valid(nil)), ErrUnsupportedAlg},
+		{"algorithm does not match the key", hs(t, map[string]any{"alg": "HS256", "kid": "ed"}, valid(nil)), ErrInvalidSignature},
+		{"unknown kid", hs(t, map[string]any{"alg": "HS256", "kid": "nope"}, valid(nil)), ErrInvalidSignature},
+		{"tampered claims", tamper(hs(t, hsHeader, valid(nil)), valid(func(c map[string]any) {
+			c["sub"] = "mallory"
+		})), ErrInvalidSignature},
+		{"wrong secret", sign(t, hsHeader, valid(nil), func(signed []byte) []byte {
+			mac := hmac.New(sha256.New, []byte("another secret of thirty-two byte"))
+			mac.Write(signed)
+			return mac.Sum(nil)
+		}), ErrInvalidSignature},
+		{"missing exp", hs(t, hsHeader, valid(func(c map[string]any) {
+			delete(c, "exp")
+		})), ErrExpired},
+		{"expired", hs(t, hsHeader, valid(func(c map[string]any) {
+			c["exp"] = testNow.Add(-2 * time.Minute).Unix()
+		})), ErrExpired},
+		{"not yet valid", hs(t, hsHeader, valid(func(c map[string]any) {
+			c["nbf"] = testNow.Add(2 * time.Minute).Unix()
+		})), ErrNotYetValid},
+		{"wrong issuer", hs(t, hsHeader, valid(func(c map[string]any) {
+			c["iss"] = "someone else"
+		})), ErrIssuer},
+		{"wrong audience", hs(t, hsHeader, valid(func(c map[string]any) {
+			c["aud"] = []string{"other"}
+		})), ErrAudience},
+		{"missing audience", hs(t, hsHeader, valid(func(c map[string]any) {
+			delete(c, "aud")
+		})), ErrAudience},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := v.Verify(tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if claims.Subject != "ada" {
+				t.Errorf("subject = %q, want ada", claims.Subject)
+			}
+		})
+	}
+}
+
+// sign builds a compact JWS; a nil claims map yields a payload that is not
+// JSON.
+func sign(t *testing.T, header, claims map[string]any, mac func(signed []byte) []byte) string {
+	t.Helper()
+	h, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte("not json")
+	if claims != nil {
+		if payload, err = json.Marshal(claims); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b64 := base64.RawURLEncoding.EncodeToString
+	signed := b64(h) + "." + b64(payload)
+	return signed + "." + b64(mac([]byte(signed)))
+}
+
+// tamper swaps the claims of token for claims, keeping its signature.
+func tamper(token string, claims map[string]any) string {
+	payload, _ := json.Marshal(claims)
+	parts := strings.Split(token, ".")
+	return parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+}
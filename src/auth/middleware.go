@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/logging"
+	"github.com/faishalshidqi/gin-introductory-proj/src/problem"
+	"github.com/gin-gonic/gin"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// KeyAuthenticator resolves an API key presented via "Authorization: ApiKey"
+// or X-API-Key to its principal.
+type KeyAuthenticator interface {
+	AuthenticateKey(ctx context.Context, key string) (*Principal, error)
+}
+
+// Authenticate rejects requests without a valid bearer token, when v is
+// non-nil, or API key, when keys is non-nil, and exposes the caller through
+// PrincipalFromContext. Requests without either are let through when
+// ClientCertificate already authenticated them.
+func Authenticate(v *Verifier, keys KeyAuthenticator) gin.HandlerFunc {
+	required := "a bearer token or api key is required"
+	switch {
+	case keys == nil:
+		required = "a bearer token is required"
+	case v == nil:
+		required = "an api key is required"
+	}
+	return func(c *gin.Context) {
+		scheme, credential, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		credential = strings.TrimSpace(credential)
+		if keys != nil && scheme == "" {
+			if key := c.GetHeader(APIKeyHeader); key != "" {
+				scheme, credential = "ApiKey", key
+			}
+		}
+
+		switch {
+		case v != nil && strings.EqualFold(scheme, "Bearer") && credential != "":
+			claims, err := v.Verify(credential)
+			if err != nil {
+				logging.FromContext(c).Info("rejected bearer token", "reason", err.Error())
+				unauthorized(c, true, keys != nil, "invalid_token", err.Error())
+				return
+			}
+			SetPrincipal(c, principalFromClaims(claims))
+		case keys != nil && strings.EqualFold(scheme, "ApiKey") && credential != "":
+			principal, err := keys.AuthenticateKey(c, credential)
+			if err != nil {
+				logging.FromContext(c).Info("rejected api key", "reason", err.Error())
+				unauthorized(c, v != nil, true, "invalid_token", err.Error())
+				return
+			}
+			SetPrincipal(c, principal)
+		case PrincipalFromContext(c) != nil:
+			// Authenticated by a client certificate; see ClientCertificate.
+		default:
+			unauthorized(c, v != nil, keys != nil, "", required)
+			return
+		}
+		c.Next()
+	}
+}
+
+// unauthorized answers 401 with an RFC 6750 challenge when bearer tokens
+// are accepted and an ApiKey challenge when keys are.
+func unauthorized(c *gin.Context, bearer, apiKeys bool, code, detail string) {
+	if bearer {
+		challenge := `Bearer realm="api"`
+		if code != "" {
+			challenge += `, error="` + code + `"`
+		}
+		c.Header("WWW-Authenticate", challenge)
+	}
+	if apiKeys {
+		c.Writer.Header().Add("WWW-Authenticate", `ApiKey realm="api"`)
+	}
+	problem.Abort(c, problem.New(http.StatusUnauthorized, detail))
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// staticKeys accepts the single key "good".
+type staticKeys struct{}
+
+func (staticKeys) AuthenticateKey(_ context.Context, key string) (*Principal, error) {
+	if key != "good" {
+		return nil, errors.New("invalid api key")
+	}
+	return &Principal{Subject: "apikey:1", Method: MethodAPIKey}, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	v, _ := newTestVerifier(t)
+	token := hs(t, map[string]any{"alg": "HS256", "kid": "hs"}, validClaims(nil))
+
+	tests := []struct {
+		name          string
+		verifier      *Verifier
+		keys          KeyAuthenticator
+		header        http.Header
+		wantStatus    int
+		wantSubject   string
+		wantChallenge []string
+	}{
+		{"bearer", v, nil, http.Header{"Authorization": {"Bearer " + token}}, 200, "ada", nil},
+		{"bad bearer", v, nil, http.Header{"Authorization": {"Bearer x.y.z"}}, 401, "", []string{`Bearer realm="api", error="invalid_token"`}},
+		{"nothing, bearer only", v, nil, nil, 401, "", []string{`Bearer realm="api"`}},
+		{"key ignored without keys", v, nil, http.Header{"X-Api-Key": {"good"}}, 401, "", []string{`Bearer realm="api"`}},
+		{"nothing, both", v, staticKeys{}, nil, 401, "", []string{`Bearer realm="api"`, `ApiKey realm="api"`}},
+		{"key header, both", v, staticKeys{}, http.Header{"X-Api-Key": {"good"}}, 200, "apikey:1", nil},
+
+		{"key header, keys only", nil, staticKeys{}, http.Header{"X-Api-Key": {"good"}}, 200, "apikey:1", nil},
+		{"key scheme, keys only", nil, staticKeys{}, http.Header{"Authorization": {"ApiKey good"}}, 200, "apikey:1", nil},
+		{"bad key, keys only", nil, staticKeys{}, http.Header{"X-Api-Key": {"bad"}}, 401, "", []string{`ApiKey realm="api"`}},
+		{"bearer, keys only", nil, staticKeys{}, http.Header{"Authorization": {"Bearer " + token}}, 401, "", []string{`ApiKey realm="api"`}},
+		{"nothing, keys only", nil, staticKeys{}, nil, 401, "", []string{`ApiKey realm="api"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subject string
+			router := gin.New()
+			router.GET("/", Authenticate(tt.verifier, tt.keys), func(c *gin.Context) {
+				subject = PrincipalFromContext(c).Subject
+				c.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, vs := range tt.header {
+				req.Header[k] = vs
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if subject != tt.wantSubject {
+				t.Errorf("subject = %q, want %q", subject, tt.wantSubject)
+			}
+			if got := w.Header().Values("WWW-Authenticate"); !slices.Equal(got, tt.wantChallenge) {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// Peer is the identity in a client certificate verified during a mutual
+// TLS handshake.
+type Peer struct {
+	// Subject is the certificate's common name.
+	Subject string
+	// URIs holds URI SANs, such as SPIFFE IDs.
+	URIs         []string
+	DNSNames     []string
+	Emails       []string
+	Issuer       string
+	SerialNumber string
+	NotAfter     time.Time
+}
+
+// ID is the identity the peer authenticates as: its first URI SAN, such
+// as a SPIFFE ID, or else its common name.
+func (p *Peer) ID() string {
+	if len(p.URIs) > 0 {
+		return p.URIs[0]
+	}
+	return p.Subject
+}
+
+func peerFromCertificate(cert *x509.Certificate) *Peer {
+	p := &Peer{
+		Subject:      cert.Subject.CommonName,
+		DNSNames:     cert.DNSNames,
+		Emails:       cert.EmailAddresses,
+		Issuer:       cert.Issuer.CommonName,
+		SerialNumber: cert.SerialNumber.Text(16),
+		NotAfter:     cert.NotAfter,
+	}
+	for _, u := range cert.URIs {
+		p.URIs = append(p.URIs, u.String())
+	}
+	return p
+}
+
+// ClientCertificate exposes the verified client certificate of a mutual
+// TLS connection through PeerFromContext and tags the request logger with
+// its identity. The certificate also authenticates the request: its ID
+// becomes the principal, with method MethodMTLS and no roles or scopes
+// of its own, unless Authenticate later finds credentials in the headers.
+// Unverified certificates are ignored, so it is safe on listeners that do
+// not verify them.
+func ClientCertificate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := c.Request.TLS
+		if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+			c.Next()
+			return
+		}
+		peer := peerFromCertificate(state.VerifiedChains[0][0])
+		c.Set(peerKey, peer)
+		ctx := context.WithValue(c.Request.Context(), peerCtxKey{}, peer)
+		ctx = logging.WithLogger(ctx, logging.FromContext(c).With("client_cert", peer.ID()))
+		c.Request = c.Request.WithContext(ctx)
+		if id := peer.ID(); id != "" {
+			SetPrincipal(c, &Principal{Subject: id, Issuer: peer.Issuer, Method: MethodMTLS})
+		}
+		c.Next()
+	}
+}
+
+const peerKey = "auth.peer"
+
+type peerCtxKey struct{}
+
+// PeerFromContext returns the client certificate identity, or nil when
+// the client presented none. ctx may be a *gin.Context.
+func PeerFromContext(ctx context.Context) *Peer {
+	if c, ok := ctx.(*gin.Context); ok {
+		if v, exists := c.Get(peerKey); exists {
+			return v.(*Peer)
+		}
+		ctx = c.Request.Context()
+	}
+	p, _ := ctx.Value(peerCtxKey{}).(*Peer)
+	return p
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPeerID(t *testing.T) {
+	tests := []struct {
+		name string
+		peer Peer
+		want string
+	}{
+		{"first uri san", Peer{Subject: "worker", URIs: []string{"spiffe://example.org/a", "spiffe://example.org/b"}}, "spiffe://example.org/a"},
+		{"common name", Peer{Subject: "worker", DNSNames: []string{"worker.example.org"}}, "worker"},
+		{"nothing", Peer{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.peer.ID(); got != tt.want {
+			t.Errorf("%s: ID() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cert := func(cn string, uris ...string) *x509.Certificate {
+		c := &x509.Certificate{
+			Subject:      pkix.Name{CommonName: cn},
+			Issuer:       pkix.Name{CommonName: "ca"},
+			SerialNumber: big.NewInt(255),
+		}
+		for _, raw := range uris {
+			u, _ := url.Parse(raw)
+			c.URIs = append(c.URIs, u)
+		}
+		return c
+	}
+	tests := []struct {
+		name  string
+		state *tls.ConnectionState
+		// wantSubject is the principal's subject, or empty for none.
+		wantSubject string
+		wantPeer    bool
+	}{
+		{name: "plain http"},
+		{name: "no verified chain", state: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert("unverified")}}},
+		{name: "uri san", state: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert("worker", "spiffe://example.org/worker")}}}, wantSubject: "spiffe://example.org/worker", wantPeer: true},
+		{name: "common name", state: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert("worker")}}}, wantSubject: "worker", wantPeer: true},
+		{name: "no identity", state: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert("")}}}, wantPeer: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var principal *Principal
+			var peer *Peer
+			router := gin.New()
+			router.Use(ClientCertificate())
+			router.GET("/", func(c *gin.Context) {
+				principal = PrincipalFromContext(c.Request.Context())
+				peer = PeerFromContext(c.Request.Context())
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.TLS = tt.state
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if (peer != nil) != tt.wantPeer {
+				t.Fatalf("peer = %+v, want one: %v", peer, tt.wantPeer)
+			}
+			if tt.wantSubject == "" {
+				if principal != nil {
+					t.Errorf("principal = %+v, want none", principal)
+				}
+				return
+			}
+			if principal == nil {
+				t.Fatal("no principal")
+			}
+			if principal.Subject != tt.wantSubject || principal.Method != MethodMTLS || principal.Issuer != "ca" {
+				t.Errorf("principal = %+v, want %s by %s from ca", principal, tt.wantSubject, MethodMTLS)
+			}
+			if len(principal.Roles) != 0 || len(principal.Scopes) != 0 {
+				t.Errorf("principal has roles %v and scopes %v, want none", principal.Roles, principal.Scopes)
+			}
+			if peer.SerialNumber != "ff" {
+				t.Errorf("serial = %q, want ff", peer.SerialNumber)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	MethodJWT    = "jwt"
+	MethodAPIKey = "apikey"
+	// MethodMTLS principals were authenticated by a client certificate.
+	MethodMTLS = "mtls"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string
+	Issuer  string
+	Method  string
+	Roles   []string
+	Scopes  []string
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return p != nil && slices.Contains(p.Roles, role)
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return p != nil && slices.Contains(p.Scopes, scope)
+}
+
+func principalFromClaims(c *Claims) *Principal {
+	return &Principal{
+		Subject: c.Subject,
+		Issuer:  c.Issuer,
+		Method:  MethodJWT,
+		Roles:   c.Roles,
+		Scopes:  strings.Fields(c.Scope),
+	}
+}
+
+const principalKey = "auth.principal"
+
+type principalCtxKey struct{}
+
+// SetPrincipal attaches p to both the gin context and the request context.
+func SetPrincipal(c *gin.Context, p *Principal) {
+	c.Set(principalKey, p)
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), principalCtxKey{}, p))
+}
+
+// PrincipalFromContext returns the authenticated principal, or nil for
+// anonymous requests. ctx may be a *gin.Context.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	if c, ok := ctx.(*gin.Context); ok {
+		if v, exists := c.Get(principalKey); exists {
+			return v.(*Principal)
+		}
+		ctx = c.Request.Context()
+	}
+	p, _ := ctx.Value(principalCtxKey{}).(*Principal)
+	return p
+}
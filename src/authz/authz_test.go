@@ -0,0 +1,166 @@
+package authz
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/auth"
+	"github.com/gin-gonic/gin"
+)
+
+const testPolicy = `
+roles:
+  admin:
+    permissions: [persons:read, persons:create, persons:update, persons:delete, api-keys:manage]
+  editor:
+    permissions: [persons:read, persons:create, persons:update:own, persons:delete:own]
+  viewer:
+    permissions: [persons:read]
+default_roles: [viewer]
+scopes:
+  persons:read:
+    permissions: [persons:read]
+  persons:write:
+    permissions: [persons:create, persons:update:own]
+`
+
+func loadTestPolicy(t *testing.T, data string) (*Policy, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return LoadPolicy(path)
+}
+
+func TestCheck(t *testing.T) {
+	p, err := loadTestPolicy(t, testPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := func(roles ...string) *auth.Principal {
+		return &auth.Principal{Subject: "ada", Method: auth.MethodJWT, Roles: roles}
+	}
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		permission string
+		want       Grant
+	}{
+		{"anonymous", nil, PersonsRead, Denied},
+		{"default role", user(), PersonsRead, Allowed},
+		{"default role only", user(), PersonsCreate, Denied},
+		{"admin", user("admin"), PersonsDelete, Allowed},
+		{"editor own", user("editor"), PersonsUpdate, OwnOnly},
+		{"editor create", user("editor"), PersonsCreate, Allowed},
+		{"unknown role", user("root"), PersonsUpdate, Denied},
+		{"roles add up", user("editor", "admin"), PersonsUpdate, Allowed},
+		{"token scope", &auth.Principal{Subject: "ada", Scopes: []string{"persons:write"}}, PersonsUpdate, OwnOnly},
+		{"unmapped scope grants nothing", &auth.Principal{Method: auth.MethodAPIKey, Scopes: []string{"persons:delete"}}, PersonsDelete, Denied},
+		{"permission name as scope grants nothing", &auth.Principal{Method: auth.MethodAPIKey, Scopes: []string{"api-keys:manage"}}, APIKeysManage, Denied},
+		{"api key scope", &auth.Principal{Method: auth.MethodAPIKey, Scopes: []string{"persons:read"}}, PersonsRead, Allowed},
+		{"api key gets no default role", &auth.Principal{Method: auth.MethodAPIKey}, PersonsRead, Denied},
+		{"api key ignores roles", &auth.Principal{Method: auth.MethodAPIKey, Roles: []string{"admin"}}, PersonsDelete, Denied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Check(tt.principal, tt.permission); got != tt.want {
+				t.Errorf("Check(%s) = %d, want %d", tt.permission, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPolicyRejects(t *testing.T) {
+	tests := []struct {
+		name, policy, wantErr string
+	}{
+		{"undefined default role", "roles: {}\ndefault_roles: [viewer]\n", `default role "viewer"`},
+		{"bad role permission", "roles:\n  r:\n    permissions: [persons]\n", `role "r"`},
+		{"bad scope permission", "scopes:\n  s:\n    permissions: [persons:read:all]\n", `scope "s"`},
+		{"unknown field", "rolez: {}\n", "rolez"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadTestPolicy(t, tt.policy)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadPolicy error = %v, want one mentioning %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExamplePolicyLoads(t *testing.T) {
+	if _, err := LoadPolicy(filepath.Join("..", "..", "policy.example.yaml")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	p, err := loadTestPolicy(t, testPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Records by ID and their creators; "broken" fails to load.
+	owners := map[string]string{"ada-1": "ada", "grace-1": "grace", "anon-1": ""}
+	owner := func(c *gin.Context) (string, bool, error) {
+		if c.Param("id") == "broken" {
+			return "", false, errors.New("store unavailable")
+		}
+		createdBy, found := owners[c.Param("id")]
+		return createdBy, found, nil
+	}
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if subject, ok := c.GetQuery("sub"); ok {
+			auth.SetPrincipal(c, &auth.Principal{
+				Subject: subject,
+				Method:  c.Query("method"),
+				Roles:   c.QueryArray("role"),
+				Scopes:  c.QueryArray("scope"),
+			})
+		}
+	})
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.POST("/persons", p.Require(PersonsCreate), ok)
+	router.PUT("/persons/:id", p.RequireOwner(PersonsUpdate, owner), ok)
+
+	tests := []struct {
+		name, method, target string
+		want                 int
+	}{
+		{"anonymous create", "POST", "/persons", http.StatusForbidden},
+		{"viewer create", "POST", "/persons?sub=ada", http.StatusForbidden},
+		{"editor create", "POST", "/persons?sub=ada&role=editor", http.StatusOK},
+		{"api key create", "POST", "/persons?sub=apikey:1&method=apikey&scope=persons:write", http.StatusOK},
+
+		{"anonymous update", "PUT", "/persons/ada-1", http.StatusForbidden},
+		{"admin updates any", "PUT", "/persons/grace-1?sub=ada&role=admin", http.StatusOK},
+		{"editor updates own", "PUT", "/persons/ada-1?sub=ada&role=editor", http.StatusOK},
+		{"editor updates another's", "PUT", "/persons/grace-1?sub=ada&role=editor", http.StatusForbidden},
+		{"editor updates anonymous record", "PUT", "/persons/anon-1?sub=ada&role=editor", http.StatusForbidden},
+		{"no subject updates anonymous record", "PUT", "/persons/anon-1?sub=&role=editor", http.StatusForbidden},
+		{"missing record reaches the handler", "PUT", "/persons/nope?sub=ada&role=editor", http.StatusOK},
+		{"viewer updates own", "PUT", "/persons/ada-1?sub=ada", http.StatusForbidden},
+		{"api key updates own", "PUT", "/persons/ada-1?sub=ada&method=apikey&scope=persons:write", http.StatusOK},
+		{"owner lookup fails", "PUT", "/persons/broken?sub=ada&role=editor", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+			if w.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d: %s", tt.method, tt.target, w.Code, tt.want, w.Body)
+			}
+			if w.Code == http.StatusForbidden && !strings.Contains(w.Body.String(), TypeForbidden) {
+				t.Errorf("403 body %s lacks type %s", w.Body, TypeForbidden)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package authz
+
+import (
+	"net/http"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/auth"
+	"github.com/faishalshidqi/gin-introductory-proj/src/problem"
+	"github.com/gin-gonic/gin"
+)
+
+const TypeForbidden = "/problems/forbidden"
+
+// OwnerFunc resolves the creator of the record a request targets. found is
+// false when the record does not exist, in which case the request proceeds
+// so the handler can answer 404.
+type OwnerFunc func(c *gin.Context) (owner string, found bool, err error)
+
+// Require allows the request only if the principal holds permission
+// outright. Use RequireOwner for routes addressing a single record.
+func (p *Policy) Require(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if p.Check(auth.PrincipalFromContext(c), permission) != Allowed {
+			forbid(c, permission)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireOwner also accepts principals holding the :own variant of
+// permission, provided owner reports them as the record's creator.
+func (p *Policy) RequireOwner(permission string, owner OwnerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := auth.PrincipalFromContext(c)
+		switch p.Check(principal, permission) {
+		case Allowed:
+			c.Next()
+			return
+		case OwnOnly:
+			createdBy, found, err := owner(c)
+			if err != nil {
+				problem.Abort(c, problem.New(http.StatusInternalServerError, err.Error()))
+				return
+			}
+			// Records created anonymously have no owner, so a principal
+			// without a subject must not match them.
+			if !found || (principal.Subject != "" && createdBy == principal.Subject) {
+				c.Next()
+				return
+			}
+			forbid(c, permission)
+		default:
+			forbid(c, permission)
+		}
+	}
+}
+
+func forbid(c *gin.Context, permission string) {
+	p := problem.New(http.StatusForbidden, "missing permission "+permission)
+	p.Type = TypeForbidden
+	problem.Abort(c, p.With("permission", permission))
+}
@@ -0,0 +1,124 @@
+package authz
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/auth"
+	"gopkg.in/yaml.v3"
+)
+
+// OwnSuffix narrows a permission to records the principal created, e.g.
+// "persons:update:own".
+const OwnSuffix = ":own"
+
+const (
+	PersonsRead   = "persons:read"
+	PersonsCreate = "persons:create"
+	PersonsUpdate = "persons:update"
+	PersonsDelete = "persons:delete"
+
+	APIKeysManage = "api-keys:manage"
+)
+
+type Role struct {
+	Permissions []string `yaml:"permissions"`
+}
+
+// Policy maps roles and credential scopes to permissions. Principals are
+// granted the permissions of their roles, of DefaultRoles, and of the
+// scopes carried by their credential as listed in Scopes; a scope the
+// policy does not list grants nothing. API keys are limited to their
+// scopes alone.
+type Policy struct {
+	Roles        map[string]Role `yaml:"roles"`
+	DefaultRoles []string        `yaml:"default_roles"`
+	Scopes       map[string]Role `yaml:"scopes"`
+}
+
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("authz: %s: %w", path, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("authz: %s: %w", path, err)
+	}
+	return &p, nil
+}
+
+func (p *Policy) Validate() error {
+	for _, name := range p.DefaultRoles {
+		if _, ok := p.Roles[name]; !ok {
+			return fmt.Errorf("default role %q is not defined", name)
+		}
+	}
+	for name, role := range p.Roles {
+		if err := validatePermissions(role.Permissions); err != nil {
+			return fmt.Errorf("role %q: %w", name, err)
+		}
+	}
+	for name, scope := range p.Scopes {
+		if err := validatePermissions(scope.Permissions); err != nil {
+			return fmt.Errorf("scope %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func validatePermissions(perms []string) error {
+	for _, perm := range perms {
+		if strings.Count(strings.TrimSuffix(perm, OwnSuffix), ":") != 1 {
+			return fmt.Errorf("permission %q is not of the form resource:action[:own]", perm)
+		}
+	}
+	return nil
+}
+
+// Grant describes how far a principal holds a permission.
+type Grant int
+
+const (
+	Denied Grant = iota
+	// OwnOnly allows the action on records the principal created.
+	OwnOnly
+	Allowed
+)
+
+func (p *Policy) permissions(principal *auth.Principal) []string {
+	var perms []string
+	for _, scope := range principal.Scopes {
+		perms = append(perms, p.Scopes[scope].Permissions...)
+	}
+	if principal.Method == auth.MethodAPIKey {
+		return perms
+	}
+	roles := append(append([]string(nil), p.DefaultRoles...), principal.Roles...)
+	for _, name := range roles {
+		perms = append(perms, p.Roles[name].Permissions...)
+	}
+	return perms
+}
+
+func (p *Policy) Check(principal *auth.Principal, permission string) Grant {
+	if principal == nil {
+		return Denied
+	}
+	perms := p.permissions(principal)
+	switch {
+	case slices.Contains(perms, permission):
+		return Allowed
+	case slices.Contains(perms, permission+OwnSuffix):
+		return OwnOnly
+	}
+	return Denied
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func runGreet(e *env, name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	r := e.remoteFlags(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError("expected exactly one name")
+	}
+	c, err := e.client(name, r)
+	if err != nil {
+		return err
+	}
+	g, err := c.Greet(context.Background(), fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return r.output.print(e.stdout, g, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, g.Message)
+	})
+}
+
+func runPersonGet(e *env, name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	r := e.remoteFlags(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return usageError("expected at most one id")
+	}
+	c, err := e.client(name, r)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		p, err := c.SamplePerson(context.Background())
+		if err != nil {
+			return err
+		}
+		return r.output.print(e.stdout, p, func(tw *tabwriter.Writer) {
+			fmt.Fprintln(tw, "FIRST NAME\tLAST NAME")
+			fmt.Fprintf(tw, "%s\t%s\n", p.FirstName, p.LastName)
+		})
+	}
+	p, err := c.GetPerson(context.Background(), fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return r.output.print(e.stdout, p, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "ID\tFIRST NAME\tLAST NAME\tVERSION\tCREATED BY\tUPDATED")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
+			p.ID, p.FirstName, p.LastName, p.Version, p.CreatedBy, formatTime(p.UpdatedAt))
+	})
+}
+
+// importTypes maps file extensions to the import formats.
+var importTypes = map[string]string{
+	".csv":    "text/csv",
+	".ndjson": "application/x-ndjson",
+	".jsonl":  "application/x-ndjson",
+	".xml":    "application/xml",
+}
+
+func runPersonImport(e *env, name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	r := e.remoteFlags(fs)
+	dryRun := fs.Bool("dry-run", false, "only validate the rows")
+	contentType := fs.String("type", "", "content type of the file; inferred from .csv, .ndjson, .jsonl or .xml, required for - (stdin)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError("expected exactly one file")
+	}
+	path := fs.Arg(0)
+	if *contentType == "" {
+		*contentType = importTypes[strings.ToLower(filepath.Ext(path))]
+		if *contentType == "" {
+			return usageError("cannot tell the format of %s; pass -type", path)
+		}
+	}
+	c, err := e.client(name, r)
+	if err != nil {
+		return err
+	}
+
+	// A file can be rewound, so an import rejected by the rate limiter is
+	// sent again; stdin cannot.
+	var body io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		body = f
+	}
+	report, err := c.ImportPersons(context.Background(), body, *contentType, *dryRun)
+	if err != nil {
+		return err
+	}
+	err = r.output.print(e.stdout, report, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "ROWS\tSUCCEEDED\tFAILED\tDRY RUN")
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%t\n", report.Rows, report.Succeeded, report.Failed, report.DryRun)
+		if len(report.Errors) == 0 {
+			return
+		}
+		fmt.Fprintln(tw, "\nROW\tLINE\tERROR")
+		for _, row := range report.Errors {
+			reasons := []string{row.Detail}
+			if row.Detail == "" {
+				reasons = reasons[:0]
+			}
+			for _, p := range row.InvalidParams {
+				reasons = append(reasons, p.Name+": "+p.Reason)
+			}
+			fmt.Fprintf(tw, "%d\t%d\t%s\n", row.Row, row.Line, strings.Join(reasons, "; "))
+		}
+		if report.ErrorsTruncated {
+			fmt.Fprintln(tw, "…\t\tfurther errors omitted")
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", report.Failed, report.Rows)
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/apikeys"
+	"github.com/faishalshidqi/gin-introductory-proj/src/config"
+)
+
+// mintedKey is what api-key create prints; the plaintext cannot be
+// retrieved again.
+type mintedKey struct {
+	apikeys.Key
+	Plaintext string `json:"key"`
+}
+
+// runAPIKeyCreate mints a key straight into the configured key file, which
+// is how the first key is made when no JWT issuer can reach the admin API.
+// The server holds the file in memory and rewrites it, so it must not be
+// running against the same file.
+func runAPIKeyCreate(e *env, name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	output := outputTable
+	fs.Var(&output, "o", "output: table, json or yaml")
+	keyName := fs.String("name", "", "name of the key")
+	scopes := fs.String("scopes", "", "comma-separated scopes, such as persons:read,api-keys:manage")
+	expiresIn := fs.Duration("expires-in", 0, "lifetime of the key; 0 never expires")
+	loader := config.NewLoader(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError("unexpected arguments %q", fs.Args())
+	}
+	if *keyName == "" || *scopes == "" {
+		return usageError("-name and -scopes are required")
+	}
+	if *expiresIn < 0 {
+		return usageError("-expires-in must not be negative")
+	}
+	cfg, _, err := loader.Load(e.lookupEnv)
+	if err != nil {
+		return err
+	}
+	if !cfg.Auth.APIKeys || cfg.Auth.APIKeysFile == "" {
+		return errors.New("auth.api_keys must be enabled with an auth.api_keys_file to mint keys into")
+	}
+
+	keys, err := apikeys.NewStore(cfg.Auth.APIKeysFile, 0)
+	if err != nil {
+		return err
+	}
+	defer keys.Close()
+	var expiresAt *time.Time
+	if *expiresIn > 0 {
+		t := time.Now().Add(*expiresIn).UTC()
+		expiresAt = &t
+	}
+	key, token, err := keys.Mint(*keyName, strings.Split(*scopes, ","), expiresAt, "cli")
+	if err != nil {
+		return err
+	}
+	minted := mintedKey{Key: key, Plaintext: token}
+	return output.print(e.stdout, minted, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "ID\tNAME\tSCOPES\tEXPIRES\tKEY")
+		var expires string
+		if key.ExpiresAt != nil {
+			expires = formatTime(*key.ExpiresAt)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, strings.Join(key.Scopes, ","), expires, token)
+	})
+}
@@ -0,0 +1,116 @@
+// Package cli implements the gin-intro command: the server itself and a
+// client for a running one, sharing the server's configuration.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	exitError        = 1
+	exitUsage        = 2
+	exitDrainTimeout = 3
+)
+
+// usageErr reports a bad invocation; the command's usage line follows it.
+type usageErr struct {
+	msg string
+}
+
+func (e *usageErr) Error() string { return e.msg }
+
+// exitStatus is returned by a command that has already reported why it
+// failed.
+type exitStatus int
+
+func (s exitStatus) Error() string {
+	return "exit status " + strconv.Itoa(int(s))
+}
+
+// env is what a command runs against, so output can be redirected.
+type env struct {
+	name      string
+	stdout    io.Writer
+	stderr    io.Writer
+	lookupEnv func(string) (string, bool)
+}
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(e *env, name string, args []string) error
+}
+
+var commands = []command{
+	{"serve", "[config flags]", "run the server", runServe},
+	{"greet", "[flags] <name>", "ask the server to greet name", runGreet},
+	{"person get", "[flags] [id]", "fetch a person, or the sample person without an id", runPersonGet},
+	{"person import", "[flags] <file>", "import persons from a CSV, NDJSON or XML file", runPersonImport},
+	{"routes", "[flags] [config flags]", "print the route table the configuration produces", runRoutes},
+	{"config validate", "[config flags]", "check the configuration and the files it names", runConfigValidate},
+	{"api-key create", "-name <name> -scopes <scopes> [flags] [config flags]", "mint an API key into the configured key file", runAPIKeyCreate},
+}
+
+// Main runs the command named by args and returns the process exit code.
+func Main(name string, args []string) int {
+	e := &env{name: name, stdout: os.Stdout, stderr: os.Stderr, lookupEnv: os.LookupEnv}
+	return e.main(args)
+}
+
+func (e *env) main(args []string) int {
+	cmd, rest, ok := lookup(args)
+	if !ok {
+		if len(args) > 0 && args[0] != "help" && args[0] != "-h" && args[0] != "-help" {
+			fmt.Fprintf(e.stderr, "%s: unknown command %q\n\n", e.name, strings.Join(args, " "))
+			e.usage()
+			return exitUsage
+		}
+		e.usage()
+		return 0
+	}
+	err := cmd.run(e, e.name+" "+cmd.name, rest)
+	var status exitStatus
+	var usage *usageErr
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &status):
+		return int(status)
+	case errors.As(err, &usage):
+		fmt.Fprintf(e.stderr, "%s %s: %v\nusage: %s %s %s\n", e.name, cmd.name, err, e.name, cmd.name, cmd.usage)
+		return exitUsage
+	default:
+		fmt.Fprintf(e.stderr, "%s %s: %v\n", e.name, cmd.name, err)
+		return exitError
+	}
+}
+
+// lookup matches the longest command name at the start of args.
+func lookup(args []string) (command, []string, bool) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):], true
+		}
+	}
+	return command{}, nil, false
+}
+
+func (e *env) usage() {
+	fmt.Fprintf(e.stderr, "usage: %s <command> [arguments]\n\ncommands:\n", e.name)
+	for _, cmd := range commands {
+		fmt.Fprintf(e.stderr, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(e.stderr, "\nRun %s <command> -h for the flags of a command.\n", e.name)
+}
+
+func usageError(format string, args ...any) error {
+	return &usageErr{msg: fmt.Sprintf(format, args...)}
+}
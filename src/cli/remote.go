@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/client"
+	"github.com/faishalshidqi/gin-introductory-proj/src/config"
+)
+
+// remote holds the flags of the commands that talk to a running server.
+type remote struct {
+	configFile string
+	url        string
+	token      string
+	apiKey     string
+	format     string
+	timeout    time.Duration
+	output     outputFormat
+	caFile     string
+	certFile   string
+	keyFile    string
+}
+
+func (e *env) remoteFlags(fs *flag.FlagSet) *remote {
+	r := &remote{output: outputTable}
+	getenv := func(name string) string {
+		v, _ := e.lookupEnv(config.EnvPrefix + name)
+		return v
+	}
+	fs.StringVar(&r.configFile, "config", "", "config file whose server.address locates the server (env "+config.EnvPrefix+"CONFIG)")
+	fs.StringVar(&r.url, "url", getenv("URL"), "base URL of the server, overriding the configured address (env "+config.EnvPrefix+"URL)")
+	fs.StringVar(&r.token, "token", getenv("TOKEN"), "JWT bearer token (env "+config.EnvPrefix+"TOKEN)")
+	fs.StringVar(&r.apiKey, "api-key", getenv("API_KEY"), "API key (env "+config.EnvPrefix+"API_KEY)")
+	fs.StringVar(&r.format, "format", "json", "wire format: json, xml, yaml, toml or protobuf")
+	fs.DurationVar(&r.timeout, "timeout", 30*time.Second, "deadline for each request, retries included")
+	fs.Var(&r.output, "o", "output: table, json or yaml")
+	fs.StringVar(&r.caFile, "ca-file", "", "PEM CAs to verify an https server with, instead of the system roots")
+	fs.StringVar(&r.certFile, "cert", "", "client certificate for mutual TLS")
+	fs.StringVar(&r.keyFile, "key", "", "private key of -cert")
+	return r
+}
+
+// client connects to -url or, failing that, to the server.address of the
+// configuration the server itself would load.
+func (e *env) client(name string, r *remote) (*client.Client, error) {
+	if r.token != "" && r.apiKey != "" {
+		return nil, usageError("-token and -api-key are mutually exclusive")
+	}
+	base := r.url
+	if base == "" {
+		var args []string
+		if r.configFile != "" {
+			args = []string{"-config", r.configFile}
+		}
+		cfg, _, err := config.LoadWithEnv(name, args, e.lookupEnv)
+		if err != nil {
+			return nil, err
+		}
+		base, err = baseURL(cfg.Server.Address, cfg.Server.Mode == "tls")
+		if err != nil {
+			return nil, err
+		}
+	}
+	transport, err := r.transport()
+	if err != nil {
+		return nil, err
+	}
+	format, err := client.ParseFormat(r.format)
+	if err != nil {
+		return nil, usageError("-format: %v", err)
+	}
+	opts := []client.Option{
+		client.WithFormat(format),
+		client.WithTimeout(r.timeout),
+		client.WithHTTPClient(&http.Client{Transport: transport}),
+	}
+	switch {
+	case r.token != "":
+		opts = append(opts, client.WithCredentials(client.BearerToken(r.token)))
+	case r.apiKey != "":
+		opts = append(opts, client.WithCredentials(client.APIKey(r.apiKey)))
+	}
+	return client.New(base, opts...)
+}
+
+// transport applies -ca-file, -cert and -key. HTTP/2 is used whenever
+// the server offers it over TLS.
+func (r *remote) transport() (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if r.caFile == "" && r.certFile == "" && r.keyFile == "" {
+		return t, nil
+	}
+	if (r.certFile == "") != (r.keyFile == "") {
+		return nil, usageError("-cert and -key go together")
+	}
+	t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig.RootCAs = x509.NewCertPool()
+		if !t.TLSClientConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s contains no PEM certificates", r.caFile)
+		}
+	}
+	if r.certFile != "" {
+		cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+	return t, nil
+}
+
+// baseURL turns a listen address such as ":9000" into a URL to reach it
+// from this host.
+func baseURL(address string, https bool) (string, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", fmt.Errorf("server.address %q: %w", address, err)
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	scheme := "http"
+	if https {
+		scheme = "https"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port), nil
+}
+
+// parse parses args with fs, which has already reported any error.
+func parse(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return exitStatus(exitUsage)
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/config"
+	"github.com/faishalshidqi/gin-introductory-proj/src/server"
+	"github.com/gin-gonic/gin"
+)
+
+// modulePath is trimmed from handler names to keep the table readable.
+const modulePath = "github.com/faishalshidqi/gin-introductory-proj/"
+
+type route struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Handler string `json:"handler"`
+}
+
+// runRoutes prints the route table the configuration produces, custom
+// methods included. The router is built without the store, key files,
+// certificates or exporters the configuration names.
+func runRoutes(e *env, name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	output := outputTable
+	fs.Var(&output, "o", "output: table, json or yaml")
+	loader := config.NewLoader(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError("unexpected arguments %q", fs.Args())
+	}
+	cfg, _, err := loader.Load(e.lookupEnv)
+	if err != nil {
+		return err
+	}
+	// Debug mode would print every route as it is registered.
+	gin.SetMode(gin.TestMode)
+	table, err := server.RouteTable(cfg)
+	if err != nil {
+		return err
+	}
+
+	var routes []route
+	for _, r := range table {
+		handler := strings.TrimPrefix(r.Handler, modulePath)
+		routes = append(routes, route{Method: r.Method, Path: r.Path, Handler: strings.TrimSuffix(handler, "-fm")})
+	}
+	return output.print(e.stdout, routes, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "METHOD\tPATH\tHANDLER")
+		for _, r := range routes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Method, r.Path, r.Handler)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/config"
+	"github.com/faishalshidqi/gin-introductory-proj/src/server"
+)
+
+// Serve runs the server configured by args until SIGINT or SIGTERM and
+// returns the process exit code.
+func Serve(name string, args []string) int {
+	cfg, opts, err := config.Load(name, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		log.Print(err)
+		return exitError
+	}
+	if opts.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Print(err)
+			return exitError
+		}
+		return 0
+	}
+
+	srv, err := server.New(cfg)
+	if err != nil {
+		log.Print(err)
+		return exitError
+	}
+	defer srv.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// Restore default signal handling so a second signal kills the
+		// process instead of waiting out the drain.
+		<-ctx.Done()
+		stop()
+	}()
+
+	err = srv.Run(ctx)
+	switch {
+	case errors.Is(err, server.ErrDrainTimeout):
+		log.Print(err)
+		return exitDrainTimeout
+	case err != nil:
+		log.Print(err)
+		return exitError
+	}
+	return 0
+}
+
+func runServe(e *env, name string, args []string) error {
+	if code := Serve(name, args); code != 0 {
+		return exitStatus(code)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/auth"
+	"github.com/faishalshidqi/gin-introductory-proj/src/authz"
+	"github.com/faishalshidqi/gin-introductory-proj/src/config"
+)
+
+// runConfigValidate loads the configuration exactly as serve would and
+// also parses the files it names, reporting every problem it finds.
+func runConfigValidate(e *env, name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	loader := config.NewLoader(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError("unexpected arguments %q", fs.Args())
+	}
+	cfg, opts, err := loader.Load(e.lookupEnv)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if cfg.Auth.Enabled {
+		keys, err := auth.LoadKeySet(cfg.Auth.JWKSFile, 0)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			keys.Close()
+		}
+	}
+	if cfg.Auth.PolicyFile != "" {
+		if _, err := authz.LoadPolicy(cfg.Auth.PolicyFile); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if tlsCfg := cfg.Server.TLS; cfg.Server.Mode == "tls" {
+		if _, err := tls.LoadX509KeyPair(tlsCfg.CertFile, tlsCfg.KeyFile); err != nil {
+			errs = append(errs, fmt.Errorf("server.tls: %w", err))
+		}
+		if tlsCfg.ClientCAFile != "" {
+			pem, err := os.ReadFile(tlsCfg.ClientCAFile)
+			if err == nil && !x509.NewCertPool().AppendCertsFromPEM(pem) {
+				err = fmt.Errorf("%s contains no PEM certificates", tlsCfg.ClientCAFile)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("server.tls: %w", err))
+			}
+		}
+	}
+	if dir := cfg.Store.DataDir; dir != "" {
+		if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+			errs = append(errs, fmt.Errorf("store.data_dir %s is not a directory", dir))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	source := "defaults and environment"
+	if opts.File != "" {
+		source = opts.File
+	}
+	fmt.Fprintf(e.stdout, "%s: configuration is valid\n", source)
+	return nil
+}
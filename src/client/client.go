@@ -0,0 +1,260 @@
+// Package client is a typed Go client for the greeting and person API.
+//
+// A Client negotiates one of the server's formats, retries transient
+// failures with jittered exponential backoff, attaches credentials to
+// every attempt and reports failures as *Error, decoded from the server's
+// problem details. Package clienttest starts a real server in memory for
+// consumers' unit tests.
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const userAgent = "gin-introductory-proj-client/1"
+
+// Client talks to one server. It is safe for concurrent use.
+type Client struct {
+	base        *url.URL
+	http        *http.Client
+	format      Format
+	credentials Credentials
+	retry       RetryPolicy
+	timeout     time.Duration
+	language    string
+	userAgent   string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sends requests through hc instead of a default client.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithFormat selects the representation the client asks for and sends.
+// The default is JSON.
+func WithFormat(f Format) Option {
+	return func(c *Client) { c.format = f }
+}
+
+// WithCredentials authenticates every request, retries included.
+func WithCredentials(cred Credentials) Option {
+	return func(c *Client) { c.credentials = cred }
+}
+
+// WithRetry replaces DefaultRetryPolicy. A policy with MaxAttempts of 1
+// disables retries.
+func WithRetry(p RetryPolicy) Option {
+	return func(c *Client) { c.retry = p }
+}
+
+// WithTimeout bounds each call, retries included, whose context has no
+// deadline of its own. The default is 30 seconds; zero disables it.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) { c.timeout = d }
+}
+
+// WithLanguage sends an Accept-Language header, which localizes greetings
+// and validation messages.
+func WithLanguage(tag string) Option {
+	return func(c *Client) { c.language = tag }
+}
+
+// WithUserAgent replaces the User-Agent header.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// New returns a client for the server at baseURL, such as
+// "http://localhost:9000".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("client: base URL: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("client: base URL %q must be http or https", baseURL)
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/")
+	c := &Client{
+		base:      base,
+		http:      http.DefaultClient,
+		format:    JSON,
+		retry:     DefaultRetryPolicy,
+		timeout:   30 * time.Second,
+		userAgent: userAgent,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if _, ok := codecs[c.format]; !ok {
+		return nil, fmt.Errorf("client: unsupported format %q", c.format)
+	}
+	return c, nil
+}
+
+// request describes one call. A body is either bytes, which can always be
+// sent again, or a stream, which can only be sent again if it seeks.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	body        []byte
+	stream      io.Reader
+	contentType string
+	accept      string
+}
+
+func (r *request) replayable() bool {
+	if r.stream == nil {
+		return true
+	}
+	_, ok := r.stream.(io.Seeker)
+	return ok
+}
+
+func (r *request) idempotent() bool {
+	switch r.method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// do sends req, retrying as the policy allows. It returns the response of
+// any status below 400, whose body the caller must close, or an *Error.
+func (c *Client) do(ctx context.Context, req *request) (*http.Response, error) {
+	cancel := context.CancelFunc(func() {})
+	if _, ok := ctx.Deadline(); !ok && c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	}
+
+	var start int64
+	if s, ok := req.stream.(io.Seeker); ok {
+		pos, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("client: %w", err)
+		}
+		start = pos
+	}
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.stream != nil {
+			if _, err := req.stream.(io.Seeker).Seek(start, io.SeekStart); err != nil {
+				cancel()
+				return nil, fmt.Errorf("client: rewinding the body: %w", err)
+			}
+		}
+		httpReq, err := c.newRequest(ctx, req)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		resp, err := c.http.Do(httpReq)
+		if err == nil && resp.StatusCode < 400 {
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+		if err == nil {
+			err = errorFrom(resp)
+		}
+		wait, retry := c.retry.next(req, err, attempt)
+		if retry {
+			retry = sleep(ctx, wait)
+		}
+		if !retry {
+			cancel()
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				err = fmt.Errorf("client: %s %s: %w", req.method, req.path, err)
+			}
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, req *request) (*http.Request, error) {
+	u := *c.base
+	u.Path += req.path
+	u.RawQuery = req.query.Encode()
+	var body io.Reader
+	switch {
+	case req.stream != nil:
+		// Hide the concrete type so net/http does not close a caller's
+		// file between attempts.
+		body = io.NopCloser(req.stream)
+	case req.body != nil:
+		body = bytes.NewReader(req.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("client: %w", err)
+	}
+	for name, values := range req.header {
+		httpReq.Header[name] = values
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	accept := req.accept
+	if accept == "" {
+		accept = string(c.format)
+	}
+	// Problem details come back as JSON whatever was asked for.
+	httpReq.Header.Set("Accept", accept+", application/problem+json;q=0.5")
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	if c.language != "" {
+		httpReq.Header.Set("Accept-Language", c.language)
+	}
+	if c.credentials != nil {
+		if err := c.credentials.Apply(httpReq); err != nil {
+			return nil, fmt.Errorf("client: credentials: %w", err)
+		}
+	}
+	return httpReq, nil
+}
+
+// call sends req and decodes a successful response into out, if not nil.
+func (c *Client) call(ctx context.Context, req *request, out any) (*http.Response, error) {
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return resp, nil
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("client: %s %s: %w", req.method, req.path, err)
+	}
+	if err := decode(resp.Header.Get("Content-Type"), data, out); err != nil {
+		return nil, fmt.Errorf("client: %s %s: decoding the response: %w", req.method, req.path, err)
+	}
+	return resp, nil
+}
+
+// cancelOnClose releases the call's timeout once a streamed body is done.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,278 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/client"
+	"github.com/faishalshidqi/gin-introductory-proj/src/client/clienttest"
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+)
+
+// attempts counts the requests a client sends: credentials are applied
+// to every attempt, retries included.
+type attempts struct{ n atomic.Int32 }
+
+func (a *attempts) Apply(*http.Request) error {
+	a.n.Add(1)
+	return nil
+}
+
+func TestRetries(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	ada := srv.Seed(models.Person{FirstName: "Ada", LastName: "Lovelace"})[0]
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		status int
+		times  int
+		call   func(c *client.Client) error
+		// wantAttempts is what the client sends; wantStatus is 0 when the
+		// call succeeds in the end.
+		wantAttempts int32
+		wantStatus   int
+	}{
+		{
+			name: "GET retried through 503s", status: 503, times: 2,
+			call: func(c *client.Client) error {
+				_, err := c.GetPerson(ctx, ada.ID)
+				return err
+			},
+			wantAttempts: 3,
+		},
+		{
+			name: "GET gives up after the last attempt", status: 503, times: 3,
+			call: func(c *client.Client) error {
+				_, err := c.GetPerson(ctx, ada.ID)
+				return err
+			},
+			wantAttempts: 3, wantStatus: 503,
+		},
+		{
+			name: "POST not retried on 503", status: 503, times: 1,
+			call: func(c *client.Client) error {
+				_, err := c.CreatePerson(ctx, models.Person{FirstName: "Grace", LastName: "Hopper"})
+				return err
+			},
+			wantAttempts: 1, wantStatus: 503,
+		},
+		{
+			name: "PATCH not retried on 502", status: 502, times: 1,
+			call: func(c *client.Client) error {
+				_, err := c.PatchPerson(ctx, ada.ID, client.PersonPatch{}, 0)
+				return err
+			},
+			wantAttempts: 1, wantStatus: 502,
+		},
+		{
+			name: "POST retried on 429", status: 429, times: 1,
+			call: func(c *client.Client) error {
+				_, err := c.CreatePerson(ctx, models.Person{FirstName: "Alan", LastName: "Turing"})
+				return err
+			},
+			wantAttempts: 2,
+		},
+		{
+			name: "import with a seekable body retried on 429", status: 429, times: 1,
+			call: func(c *client.Client) error {
+				report, err := c.ImportPersons(ctx, strings.NewReader("firstName,lastName\nEdsger,Dijkstra\n"), "text/csv", true)
+				if err == nil && report.Succeeded != 1 {
+					t.Errorf("import report = %+v, want the row replayed whole", report)
+				}
+				return err
+			},
+			wantAttempts: 2,
+		},
+		{
+			name: "400 not retried", status: 400, times: 1,
+			call: func(c *client.Client) error {
+				_, err := c.GetPerson(ctx, ada.ID)
+				return err
+			},
+			wantAttempts: 1, wantStatus: 400,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent attempts
+			srv.Fail(tt.status, tt.times)
+			err := tt.call(srv.Client(client.WithCredentials(&sent)))
+			if got := sent.n.Load(); got != tt.wantAttempts {
+				t.Errorf("sent %d attempts, want %d", got, tt.wantAttempts)
+			}
+			var apiErr *client.Error
+			switch {
+			case tt.wantStatus == 0 && err != nil:
+				t.Fatalf("call failed: %v", err)
+			case tt.wantStatus == 0:
+			case !errors.As(err, &apiErr):
+				t.Fatalf("err = %v, want an *Error", err)
+			case apiErr.StatusCode != tt.wantStatus:
+				t.Errorf("status = %d, want %d", apiErr.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestProblemErrors(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+
+	_, err := c.GetPerson(ctx, "no-such-id")
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want an *Error", err)
+	}
+	if !errors.Is(err, client.ErrNotFound) || errors.Is(err, client.ErrConflict) {
+		t.Errorf("err = %v, want it to match ErrNotFound only", err)
+	}
+	if apiErr.StatusCode != 404 || apiErr.Title == "" || apiErr.Detail == "" {
+		t.Errorf("error = %+v, want the server's problem details", apiErr)
+	}
+
+	_, err = c.CreatePerson(ctx, models.Person{FirstName: "Ada"})
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want an *Error", err)
+	}
+	if apiErr.StatusCode != 400 || len(apiErr.InvalidParams) == 0 {
+		t.Fatalf("error = %+v, want a 400 with invalid params", apiErr)
+	}
+	var found bool
+	for _, p := range apiErr.InvalidParams {
+		found = found || p.Name == "lastName" && p.Reason != ""
+	}
+	if !found {
+		t.Errorf("invalid params = %+v, want one for lastName", apiErr.InvalidParams)
+	}
+
+	srv.Fail(http.StatusConflict, 1)
+	_, err = c.GetPerson(ctx, "anything")
+	if !errors.As(err, &apiErr) || !errors.Is(err, client.ErrConflict) {
+		t.Fatalf("err = %v, want ErrConflict", err)
+	}
+	if apiErr.Detail != "injected by clienttest" || apiErr.Type != "about:blank" {
+		t.Errorf("error = %+v, want the injected problem", apiErr)
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	// Each case gets its own server, so a failure one leaves queued
+	// cannot reach the next.
+	newServer := func(t *testing.T) (*clienttest.Server, models.Person) {
+		srv := clienttest.NewServer()
+		t.Cleanup(srv.Close)
+		return srv, srv.Seed(models.Person{FirstName: "Ada", LastName: "Lovelace"})[0]
+	}
+	// Waits drawn below an hour of jitter practically always outlast the
+	// contexts below.
+	slow := client.WithRetry(client.RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour, MaxBackoff: time.Hour})
+
+	t.Run("cancelled before sending", func(t *testing.T) {
+		srv, ada := newServer(t)
+		var sent attempts
+		c := srv.Client(client.WithCredentials(&sent))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := c.GetPerson(ctx, ada.ID)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+		if n := sent.n.Load(); n > 1 {
+			t.Errorf("sent %d attempts, want no retries", n)
+		}
+	})
+
+	t.Run("no retry past the deadline", func(t *testing.T) {
+		srv, ada := newServer(t)
+		c := srv.Client(slow)
+		srv.Fail(http.StatusServiceUnavailable, 5)
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := c.GetPerson(ctx, ada.ID)
+		if !isStatus(err, 503) {
+			t.Errorf("err = %v, want the 503", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("call took %v; a wait past the deadline should not start", elapsed)
+		}
+	})
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		srv, ada := newServer(t)
+		// Without the client's own timeout the context has no deadline,
+		// so the wait starts and only cancellation ends it.
+		c := srv.Client(slow, client.WithTimeout(0))
+		srv.Fail(http.StatusServiceUnavailable, 5)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		start := time.Now()
+		_, err := c.GetPerson(ctx, ada.ID)
+		if !isStatus(err, 503) {
+			t.Errorf("err = %v, want the 503 that was being retried", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("call took %v after cancellation", elapsed)
+		}
+	})
+}
+
+func isStatus(err error, status int) bool {
+	var apiErr *client.Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+func TestIfMatch(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	for _, format := range []client.Format{client.JSON, client.XML, client.YAML, client.TOML, client.Protobuf} {
+		t.Run(string(format), func(t *testing.T) {
+			c := srv.Client(client.WithFormat(format))
+			p, err := c.CreatePerson(ctx, models.Person{FirstName: "Ada", LastName: "Byron"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			stale := p.Version
+
+			p.LastName = "Lovelace"
+			updated, err := c.UpdatePerson(ctx, p)
+			if err != nil {
+				t.Fatalf("update at the current version: %v", err)
+			}
+			if updated.Version == stale {
+				t.Fatalf("version stayed at %d after an update", stale)
+			}
+			p.Version = stale
+			if _, err := c.UpdatePerson(ctx, p); !errors.Is(err, client.ErrPreconditionFailed) {
+				t.Errorf("update at a stale version: err = %v, want ErrPreconditionFailed", err)
+			}
+
+			first := "Augusta"
+			patched, err := c.PatchPerson(ctx, p.ID, client.PersonPatch{FirstName: &first}, updated.Version)
+			if err != nil {
+				t.Fatalf("patch at the current version: %v", err)
+			}
+			if _, err := c.PatchPerson(ctx, p.ID, client.PersonPatch{FirstName: &first}, stale); !errors.Is(err, client.ErrPreconditionFailed) {
+				t.Errorf("patch at a stale version: err = %v, want ErrPreconditionFailed", err)
+			}
+
+			if err := c.DeletePerson(ctx, p.ID, updated.Version); !errors.Is(err, client.ErrPreconditionFailed) {
+				t.Errorf("delete at a stale version: err = %v, want ErrPreconditionFailed", err)
+			}
+			if err := c.DeletePerson(ctx, p.ID, patched.Version); err != nil {
+				t.Errorf("delete at the current version: %v", err)
+			}
+		})
+	}
+}
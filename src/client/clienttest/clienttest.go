@@ -0,0 +1,115 @@
+// Package clienttest runs the real API in memory for unit tests of code
+// that uses package client.
+package clienttest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/client"
+	"github.com/faishalshidqi/gin-introductory-proj/src/config"
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+	"github.com/faishalshidqi/gin-introductory-proj/src/server"
+)
+
+// Server serves the API from an in-memory store on a local port.
+type Server struct {
+	*httptest.Server
+	API *server.Server
+
+	mu       sync.Mutex
+	failures []failure
+}
+
+type failure struct {
+	status int
+	times  int
+}
+
+// NewServer starts a server with the default configuration, a memory
+// store and quiet logs; opts adjust the configuration before it starts.
+// It panics if the configuration is rejected, like httptest.NewServer
+// does when it cannot listen.
+func NewServer(opts ...func(*config.Config)) *Server {
+	cfg := config.Default()
+	cfg.Gin.Mode = "test"
+	cfg.Log.Level = "error"
+	cfg.Store.DataDir = ""
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	api, err := server.New(cfg)
+	if err != nil {
+		panic("clienttest: " + err.Error())
+	}
+	s := &Server{API: api}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Client returns a client for the server. Retries wait at most a
+// millisecond so tests of retry behaviour stay fast.
+func (s *Server) Client(opts ...client.Option) *client.Client {
+	fast := client.WithRetry(client.RetryPolicy{MaxAttempts: client.DefaultRetryPolicy.MaxAttempts, MaxBackoff: 1})
+	c, err := client.New(s.URL, append([]client.Option{fast, client.WithHTTPClient(s.Server.Client())}, opts...)...)
+	if err != nil {
+		panic("clienttest: " + err.Error())
+	}
+	return c
+}
+
+// Seed stores persons directly, bypassing authentication, and returns
+// them as stored.
+func (s *Server) Seed(persons ...models.Person) []models.Person {
+	stored := make([]models.Person, len(persons))
+	for i, p := range persons {
+		created, err := s.API.Store.Create(context.Background(), p)
+		if err != nil {
+			panic("clienttest: " + err.Error())
+		}
+		stored[i] = created
+	}
+	return stored
+}
+
+// Fail answers the next times requests with status before they reach the
+// API, to exercise a client's error handling and retries. Calls queue up.
+func (s *Server) Fail(status, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, failure{status: status, times: times})
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if status, ok := s.nextFailure(); ok {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"type":"about:blank","title":%q,"status":%d,"detail":"injected by clienttest"}`, http.StatusText(status), status)
+		return
+	}
+	s.API.Router.ServeHTTP(w, r)
+}
+
+func (s *Server) nextFailure() (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failures) == 0 {
+		return 0, false
+	}
+	f := &s.failures[0]
+	f.times--
+	status := f.status
+	if f.times <= 0 {
+		s.failures = s.failures[1:]
+	}
+	return status, true
+}
+
+// Close stops the listener and releases the API's resources.
+func (s *Server) Close() {
+	s.Server.Close()
+	s.API.Close()
+}
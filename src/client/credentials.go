@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Credentials authenticate a request. Apply is called on every attempt,
+// so an implementation may refresh what it sends.
+type Credentials interface {
+	Apply(req *http.Request) error
+}
+
+// BearerToken sends a JWT as "Authorization: Bearer <token>".
+type BearerToken string
+
+func (t BearerToken) Apply(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+string(t))
+	return nil
+}
+
+// APIKey sends a scoped API key in the X-API-Key header.
+type APIKey string
+
+func (k APIKey) Apply(req *http.Request) error {
+	req.Header.Set("X-API-Key", string(k))
+	return nil
+}
+
+// TokenSource fetches a bearer token for each attempt, for tokens that
+// expire while a client is in use.
+type TokenSource func(ctx context.Context) (string, error)
+
+func (f TokenSource) Apply(req *http.Request) error {
+	token, err := f(req.Context())
+	if err != nil {
+		return err
+	}
+	return BearerToken(token).Apply(req)
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Sentinel errors matched by errors.Is against an *Error's status.
+var (
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("forbidden")
+	ErrNotFound           = errors.New("not found")
+	ErrConflict           = errors.New("conflict")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrRateLimited        = errors.New("rate limited")
+)
+
+var statusErrors = map[error]int{
+	ErrUnauthorized:       http.StatusUnauthorized,
+	ErrForbidden:          http.StatusForbidden,
+	ErrNotFound:           http.StatusNotFound,
+	ErrConflict:           http.StatusConflict,
+	ErrPreconditionFailed: http.StatusPreconditionFailed,
+	ErrRateLimited:        http.StatusTooManyRequests,
+}
+
+// Error is a failed response, carrying the server's RFC 7807 problem
+// details when it sent them.
+type Error struct {
+	StatusCode    int            `json:"status"`
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+	// RetryAfter is the wait the server asked for on a 429 or 503.
+	RetryAfter time.Duration `json:"-"`
+}
+
+// InvalidParam is one failing field of a rejected request. Pointer, when
+// set, locates it in the body as a JSON Pointer fragment.
+type InvalidParam struct {
+	Name    string `json:"name"`
+	Pointer string `json:"pointer,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("client: %d %s", e.StatusCode, e.Title)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	for _, p := range e.InvalidParams {
+		msg += fmt.Sprintf("; %s: %s", p.Name, p.Reason)
+	}
+	return msg
+}
+
+func (e *Error) Is(target error) bool {
+	status, ok := statusErrors[target]
+	return ok && e.StatusCode == status
+}
+
+// errorFrom reads and closes the body of a failed response.
+func errorFrom(resp *http.Response) *Error {
+	defer resp.Body.Close()
+	e := &Error{}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if mediaType == "application/problem+json" || mediaType == string(JSON) {
+		json.Unmarshal(data, e)
+	}
+	e.StatusCode = resp.StatusCode
+	if e.Title == "" {
+		e.Title = http.StatusText(resp.StatusCode)
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(resp.Header.Get("Retry-After")); err == nil {
+		e.RetryAfter = max(time.Until(at), 0)
+	}
+	return e
+}
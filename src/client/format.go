@@ -0,0 +1,133 @@
+package client
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+	"github.com/faishalshidqi/gin-introductory-proj/src/models/pb"
+	"github.com/pelletier/go-toml/v2"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a representation the server can negotiate, named by its
+// media type.
+type Format string
+
+const (
+	JSON     Format = "application/json"
+	XML      Format = "application/xml"
+	YAML     Format = "application/x-yaml"
+	TOML     Format = "application/toml"
+	Protobuf Format = "application/x-protobuf"
+)
+
+// ParseFormat accepts a format's short name, such as "xml", or its media
+// type.
+func ParseFormat(name string) (Format, error) {
+	for f, c := range codecs {
+		if name == c.name || name == string(f) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("client: unknown format %q", name)
+}
+
+type codec struct {
+	name      string
+	marshal   func(any) ([]byte, error)
+	unmarshal func([]byte, any) error
+}
+
+var codecs = map[Format]codec{
+	JSON:     {"json", json.Marshal, json.Unmarshal},
+	XML:      {"xml", xml.Marshal, xml.Unmarshal},
+	YAML:     {"yaml", yaml.Marshal, yaml.Unmarshal},
+	TOML:     {"toml", toml.Marshal, toml.Unmarshal},
+	Protobuf: {"protobuf", marshalProto, unmarshalProto},
+}
+
+// mediaTypes maps the aliases the server may answer with to a Format.
+var mediaTypes = map[string]Format{
+	"text/xml":                 XML,
+	"application/yaml":         YAML,
+	"application/problem+json": JSON,
+}
+
+// accepts reports whether responses of type v can be decoded from f.
+// Only the person and greeting models have a protobuf counterpart.
+func accepts(f Format, v any) bool {
+	if f != Protobuf {
+		return true
+	}
+	switch v.(type) {
+	case *models.Person, *models.SamplePerson, *models.PersonList, *models.Greeting:
+		return true
+	}
+	return false
+}
+
+func encode(f Format, v any) ([]byte, error) {
+	return codecs[f].marshal(v)
+}
+
+func decode(contentType string, data []byte, v any) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("content type %q: %w", contentType, err)
+	}
+	f := Format(mediaType)
+	if alias, ok := mediaTypes[mediaType]; ok {
+		f = alias
+	}
+	c, ok := codecs[f]
+	if !ok {
+		return fmt.Errorf("unexpected content type %q", mediaType)
+	}
+	return c.unmarshal(data, v)
+}
+
+func marshalProto(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case models.Person:
+		return proto.Marshal(v.ToProto())
+	case *models.Person:
+		return proto.Marshal(v.ToProto())
+	}
+	return nil, fmt.Errorf("%T has no protobuf encoding", v)
+}
+
+func unmarshalProto(data []byte, v any) error {
+	switch v := v.(type) {
+	case *models.Person:
+		var msg pb.Person
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		*v = models.PersonFromProto(&msg)
+	case *models.SamplePerson:
+		var msg pb.Person
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		*v = models.SamplePerson{FirstName: msg.GetFirstName(), LastName: msg.GetLastName()}
+	case *models.PersonList:
+		var msg pb.PersonList
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		*v = models.PersonListFromProto(&msg)
+	case *models.Greeting:
+		var msg pb.Greeting
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		*v = models.Greeting{Message: msg.GetMessage()}
+	default:
+		return fmt.Errorf("%T has no protobuf encoding", v)
+	}
+	return nil
+}
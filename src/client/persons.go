@@ -0,0 +1,285 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+)
+
+// ListOptions selects a page of GET /persons. Zero values use the server's
+// defaults.
+type ListOptions struct {
+	Limit int
+	// Sort lists fields, each optionally prefixed with - for descending,
+	// as in "lastName,-createdAt".
+	Sort string
+	// Filter holds name filters such as firstName=Ada or
+	// lastName[iprefix]=love.
+	Filter url.Values
+	// Cursor continues from Page.Next or Page.Prev.
+	Cursor string
+}
+
+// Page is one page of a listing. Next and Prev are empty at either end.
+type Page struct {
+	Persons []models.Person
+	Next    string
+	Prev    string
+}
+
+// PersonPatch changes the fields that are not nil.
+type PersonPatch struct {
+	FirstName *string `json:"firstName,omitempty"`
+	LastName  *string `json:"lastName,omitempty"`
+}
+
+// ImportReport is the outcome of ImportPersons.
+type ImportReport struct {
+	DryRun          bool          `json:"dryRun"`
+	Rows            int           `json:"rows"`
+	Succeeded       int           `json:"succeeded"`
+	Failed          int           `json:"failed"`
+	Errors          []ImportError `json:"errors,omitempty"`
+	ErrorsTruncated bool          `json:"errorsTruncated,omitempty"`
+}
+
+// ImportError describes one failed row. Row counts records from 1; Line
+// is where the record starts in the file.
+type ImportError struct {
+	Row           int            `json:"row"`
+	Line          int            `json:"line,omitempty"`
+	Detail        string         `json:"detail,omitempty"`
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+// Greet asks IndexHandler to greet name.
+func (c *Client) Greet(ctx context.Context, name string) (models.Greeting, error) {
+	var g models.Greeting
+	_, err := c.call(ctx, c.read("/"+url.PathEscape(name), nil, &g), &g)
+	return g, err
+}
+
+// SamplePerson fetches the fixed person PersonHandler serves at /person.
+func (c *Client) SamplePerson(ctx context.Context) (models.SamplePerson, error) {
+	var p models.SamplePerson
+	_, err := c.call(ctx, c.read("/person", nil, &p), &p)
+	return p, err
+}
+
+func (c *Client) ListPersons(ctx context.Context, opts ListOptions) (*Page, error) {
+	query := url.Values{}
+	for name, values := range opts.Filter {
+		query[name] = values
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Sort != "" {
+		query.Set("sort", opts.Sort)
+	}
+	if opts.Cursor != "" {
+		query.Set("cursor", opts.Cursor)
+	}
+	var list models.PersonList
+	resp, err := c.call(ctx, c.read("/persons", query, &list), &list)
+	if err != nil {
+		return nil, err
+	}
+	page := &Page{Persons: list.Persons}
+	for _, header := range resp.Header.Values("Link") {
+		for _, link := range strings.Split(header, ",") {
+			target, rel, ok := parseLink(link)
+			if !ok {
+				continue
+			}
+			switch rel {
+			case "next":
+				page.Next = target.Query().Get("cursor")
+			case "prev":
+				page.Prev = target.Query().Get("cursor")
+			}
+		}
+	}
+	return page, nil
+}
+
+// AllPersons walks every page of the listing opts selects, starting at
+// opts.Cursor. Iteration stops at the first error.
+func (c *Client) AllPersons(ctx context.Context, opts ListOptions) iter.Seq2[models.Person, error] {
+	return func(yield func(models.Person, error) bool) {
+		for {
+			page, err := c.ListPersons(ctx, opts)
+			if err != nil {
+				yield(models.Person{}, err)
+				return
+			}
+			for _, p := range page.Persons {
+				if !yield(p, nil) {
+					return
+				}
+			}
+			if page.Next == "" {
+				return
+			}
+			opts.Cursor = page.Next
+		}
+	}
+}
+
+// SearchPersons returns the best matches for q first. A limit of 0 uses
+// the server's default.
+func (c *Client) SearchPersons(ctx context.Context, q string, limit int) ([]models.Person, error) {
+	query := url.Values{"q": {q}}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var list models.PersonList
+	if _, err := c.call(ctx, c.read("/persons/search", query, &list), &list); err != nil {
+		return nil, err
+	}
+	return list.Persons, nil
+}
+
+func (c *Client) GetPerson(ctx context.Context, id string) (models.Person, error) {
+	var p models.Person
+	_, err := c.call(ctx, c.read("/persons/"+url.PathEscape(id), nil, &p), &p)
+	return p, err
+}
+
+// CreatePerson stores the names of p; the server assigns everything else.
+func (c *Client) CreatePerson(ctx context.Context, p models.Person) (models.Person, error) {
+	req, err := c.write(http.MethodPost, "/persons", p)
+	if err != nil {
+		return models.Person{}, err
+	}
+	var created models.Person
+	_, err = c.call(ctx, req, &created)
+	return created, err
+}
+
+// UpdatePerson replaces the names of the person p.ID. When p.Version is
+// set the update only applies to that version and fails with
+// ErrPreconditionFailed otherwise.
+func (c *Client) UpdatePerson(ctx context.Context, p models.Person) (models.Person, error) {
+	req, err := c.write(http.MethodPut, "/persons/"+url.PathEscape(p.ID), p)
+	if err != nil {
+		return models.Person{}, err
+	}
+	c.ifMatch(req, p.Version)
+	var updated models.Person
+	_, err = c.call(ctx, req, &updated)
+	return updated, err
+}
+
+// PatchPerson applies patch as a JSON Merge Patch, conditional on version
+// unless it is 0.
+func (c *Client) PatchPerson(ctx context.Context, id string, patch PersonPatch, version int64) (models.Person, error) {
+	body, err := encode(JSON, patch)
+	if err != nil {
+		return models.Person{}, fmt.Errorf("client: %w", err)
+	}
+	var patched models.Person
+	req := c.read("/persons/"+url.PathEscape(id), nil, &patched)
+	req.method = http.MethodPatch
+	req.body = body
+	req.contentType = "application/merge-patch+json"
+	c.ifMatch(req, version)
+	_, err = c.call(ctx, req, &patched)
+	return patched, err
+}
+
+// DeletePerson deletes the person id, conditional on version unless it
+// is 0.
+func (c *Client) DeletePerson(ctx context.Context, id string, version int64) error {
+	req := &request{method: http.MethodDelete, path: "/persons/" + url.PathEscape(id)}
+	c.ifMatch(req, version)
+	_, err := c.call(ctx, req, nil)
+	return err
+}
+
+// ImportPersons streams a CSV, NDJSON or XML file of persons, named by
+// contentType, to the server. With dryRun the rows are only validated.
+// The body is only sent again on a retry if it implements io.Seeker.
+func (c *Client) ImportPersons(ctx context.Context, body io.Reader, contentType string, dryRun bool) (*ImportReport, error) {
+	req := &request{
+		method:      http.MethodPost,
+		path:        "/persons:import",
+		query:       url.Values{"dryRun": {strconv.FormatBool(dryRun)}},
+		stream:      body,
+		contentType: contentType,
+		accept:      string(JSON),
+	}
+	var report ImportReport
+	if _, err := c.call(ctx, req, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ExportPersons streams every person as contentType: text/csv,
+// application/x-ndjson or application/xml. The caller must close the
+// returned body.
+func (c *Client) ExportPersons(ctx context.Context, contentType string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, &request{method: http.MethodGet, path: "/persons:export", accept: contentType})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// read prepares a GET whose response decodes into out, falling back to
+// JSON when out has no representation in the client's format.
+func (c *Client) read(path string, query url.Values, out any) *request {
+	req := &request{method: http.MethodGet, path: path, query: query}
+	if !accepts(c.format, out) {
+		req.accept = string(JSON)
+	}
+	return req
+}
+
+// write prepares a request carrying p in the client's format.
+func (c *Client) write(method, path string, p models.Person) (*request, error) {
+	body, err := encode(c.format, p)
+	if err != nil {
+		return nil, fmt.Errorf("client: %w", err)
+	}
+	return &request{method: method, path: path, body: body, contentType: string(c.format)}, nil
+}
+
+// ifMatch makes req conditional on version. The server tags each format of
+// a version apart and accepts any of them, so the client's own is used.
+func (c *Client) ifMatch(req *request, version int64) {
+	if version == 0 {
+		return
+	}
+	if req.header == nil {
+		req.header = http.Header{}
+	}
+	req.header.Set("If-Match", `"`+strconv.FormatInt(version, 10)+"-"+codecs[c.format].name+`"`)
+}
+
+// parseLink reads one RFC 8288 link value of the form <url>; rel="next".
+func parseLink(link string) (*url.URL, string, bool) {
+	target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+	if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+		return nil, "", false
+	}
+	u, err := url.Parse(target[1 : len(target)-1])
+	if err != nil {
+		return nil, "", false
+	}
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if strings.EqualFold(name, "rel") {
+			return u, strings.Trim(value, `"`), true
+		}
+	}
+	return nil, "", false
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy decides how often and how patiently a call is retried.
+//
+// Network errors and 502, 503 and 504 responses are retried for
+// idempotent methods only, since the server may have acted on the first
+// attempt. 429 responses are retried for every method because a limited
+// request was never processed. A Retry-After header stretches the wait,
+// and no retry is attempted that would end past the context's deadline.
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt; 1 disables retries.
+	MaxAttempts int
+	// InitialBackoff caps the first wait; each retry doubles the cap up
+	// to MaxBackoff. The actual wait is drawn uniformly below the cap.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+}
+
+// next reports whether to retry after attempt failed with err, and how
+// long to wait first.
+func (p RetryPolicy) next(req *request, err error, attempt int) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || !req.replayable() {
+		return 0, false
+	}
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return 0, false
+		}
+		return p.backoff(attempt), req.idempotent()
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests:
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if !req.idempotent() {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	return max(p.backoff(attempt), apiErr.RetryAfter), true
+}
+
+// backoff draws a wait with full jitter, so that clients failing together
+// do not retry together.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	limit := p.InitialBackoff
+	for i := 1; i < attempt && limit < p.MaxBackoff; i++ {
+		limit *= 2
+	}
+	limit = min(limit, p.MaxBackoff)
+	if limit <= 0 {
+		return 0
+	}
+	return rand.N(limit)
+}
+
+// sleep waits d unless ctx ends first or would end before d is up; it
+// reports whether the wait completed.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return false
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackoffJitter(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	tests := []struct {
+		attempt int
+		limit   time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{9, time.Second},
+	}
+	for _, tt := range tests {
+		seen := make(map[time.Duration]bool)
+		var high bool
+		for range 200 {
+			d := p.backoff(tt.attempt)
+			if d < 0 || d >= tt.limit {
+				t.Fatalf("attempt %d: backoff %v outside [0, %v)", tt.attempt, d, tt.limit)
+			}
+			seen[d] = true
+			high = high || d >= tt.limit/2
+		}
+		// Full jitter spreads the waits over the whole range.
+		if len(seen) < 100 || !high {
+			t.Errorf("attempt %d: %d distinct waits, upper half reached %v", tt.attempt, len(seen), high)
+		}
+	}
+	if d := (RetryPolicy{}).backoff(1); d != 0 {
+		t.Errorf("backoff without a cap = %v, want 0", d)
+	}
+}
+
+func TestRetryNext(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	status := func(code int) error { return &Error{StatusCode: code} }
+	network := errors.New("connection reset")
+	tests := []struct {
+		name      string
+		req       request
+		err       error
+		attempt   int
+		wantRetry bool
+		minWait   time.Duration
+	}{
+		{name: "GET 503", req: request{method: http.MethodGet}, err: status(503), wantRetry: true},
+		{name: "PUT 502", req: request{method: http.MethodPut}, err: status(502), wantRetry: true},
+		{name: "DELETE 504", req: request{method: http.MethodDelete}, err: status(504), wantRetry: true},
+		{name: "POST 503", req: request{method: http.MethodPost}, err: status(503)},
+		{name: "PATCH 503", req: request{method: http.MethodPatch}, err: status(503)},
+		{name: "POST 429", req: request{method: http.MethodPost}, err: status(429), wantRetry: true},
+		{name: "GET 500", req: request{method: http.MethodGet}, err: status(500)},
+		{name: "GET 404", req: request{method: http.MethodGet}, err: status(404)},
+		{name: "GET network error", req: request{method: http.MethodGet}, err: network, wantRetry: true},
+		{name: "POST network error", req: request{method: http.MethodPost}, err: network},
+		{name: "cancelled", req: request{method: http.MethodGet}, err: context.Canceled},
+		{name: "deadline", req: request{method: http.MethodGet}, err: context.DeadlineExceeded},
+		{name: "last attempt", req: request{method: http.MethodGet}, err: status(503), attempt: 3},
+		{
+			name: "stream that cannot rewind",
+			req:  request{method: http.MethodPost, stream: io.MultiReader(strings.NewReader("x"))},
+			err:  status(429),
+		},
+		{
+			name:      "stream that rewinds",
+			req:       request{method: http.MethodPost, stream: strings.NewReader("x")},
+			err:       status(429),
+			wantRetry: true,
+		},
+		{
+			name:      "Retry-After",
+			req:       request{method: http.MethodPost},
+			err:       &Error{StatusCode: 429, RetryAfter: 5 * time.Second},
+			wantRetry: true,
+			minWait:   5 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempt := max(tt.attempt, 1)
+			wait, retry := p.next(&tt.req, tt.err, attempt)
+			if retry != tt.wantRetry {
+				t.Fatalf("retry = %v, want %v", retry, tt.wantRetry)
+			}
+			if retry && wait < tt.minWait {
+				t.Errorf("wait = %v, want at least %v", wait, tt.minWait)
+			}
+		})
+	}
+}
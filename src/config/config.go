@@ -0,0 +1,211 @@
+package config
+
+import (
+	"time"
+)
+
+const EnvPrefix = "GIN_INTRO_"
+
+type Config struct {
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Gin       GinConfig       `yaml:"gin" toml:"gin"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Store     StoreConfig     `yaml:"store" toml:"store"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Paging    PagingConfig    `yaml:"paging" toml:"paging"`
+	OpenAPI   OpenAPIConfig   `yaml:"openapi" toml:"openapi"`
+	Features  FeatureConfig   `yaml:"features" toml:"features"`
+
+	// sources records where each key's effective value came from.
+	sources map[string]string
+}
+
+type ServerConfig struct {
+	// Mode is http for HTTP/1.1, h2c for HTTP/2 over cleartext next to
+	// HTTP/1.1, or tls for HTTPS with HTTP/2.
+	Mode              string        `yaml:"mode" toml:"mode"`
+	Address           string        `yaml:"address" toml:"address"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// DrainDelay keeps serving after readiness starts failing so load
+	// balancers can stop routing here before the listener closes.
+	DrainDelay      time.Duration `yaml:"drain_delay" toml:"drain_delay"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	TLS             TLSConfig     `yaml:"tls" toml:"tls"`
+}
+
+// TLSConfig applies in the tls server mode. The files are reloaded when
+// they change, so certificates can be rotated without a restart.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file" toml:"cert_file"`
+	KeyFile  string `yaml:"key_file" toml:"key_file"`
+	// ClientCAFile enables mutual TLS: client certificates must chain to
+	// one of these CAs, and a verified certificate authenticates its
+	// client, whose first URI SAN or else common name becomes the
+	// principal's subject.
+	ClientCAFile string `yaml:"client_ca_file" toml:"client_ca_file"`
+	// ClientAuth is require, or optional to verify a certificate only
+	// when the client presents one, e.g. to let health probes through.
+	ClientAuth     string        `yaml:"client_auth" toml:"client_auth"`
+	ReloadInterval time.Duration `yaml:"reload_interval" toml:"reload_interval"`
+}
+
+type GinConfig struct {
+	Mode           string   `yaml:"mode" toml:"mode"`
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+}
+
+type LogConfig struct {
+	Format string `yaml:"format" toml:"format"`
+	Level  string `yaml:"level" toml:"level"`
+}
+
+type StoreConfig struct {
+	DataDir          string `yaml:"data_dir" toml:"data_dir"`
+	CompactThreshold int    `yaml:"compact_threshold" toml:"compact_threshold"`
+}
+
+type TracingConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Endpoint is the collector's OTLP/HTTP traces URL, e.g.
+	// http://localhost:4318/v1/traces.
+	Endpoint    string  `yaml:"endpoint" toml:"endpoint"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+	ServiceName string  `yaml:"service_name" toml:"service_name"`
+}
+
+type AuthConfig struct {
+	// Enabled requires a JWT bearer token on the person API.
+	Enabled        bool          `yaml:"enabled" toml:"enabled"`
+	JWKSFile       string        `yaml:"jwks_file" toml:"jwks_file"`
+	ReloadInterval time.Duration `yaml:"reload_interval" toml:"reload_interval"`
+	Issuer         string        `yaml:"issuer" toml:"issuer"`
+	Audience       string        `yaml:"audience" toml:"audience"`
+	ClockSkew      time.Duration `yaml:"clock_skew" toml:"clock_skew"`
+	// PolicyFile enables role-based authorization from a YAML policy.
+	PolicyFile string `yaml:"policy_file" toml:"policy_file"`
+	// APIKeys accepts scoped API keys, next to JWTs or on their own, and
+	// mounts the /admin/api-keys management API. The first key is minted
+	// with the api-key create command.
+	APIKeys bool `yaml:"api_keys" toml:"api_keys"`
+	// APIKeysFile persists the keys. Without it they live in memory and
+	// are lost on restart.
+	APIKeysFile string `yaml:"api_keys_file" toml:"api_keys_file"`
+	// APIKeysFlushInterval is how often last-used times are written to
+	// APIKeysFile; other changes are written at once.
+	APIKeysFlushInterval time.Duration `yaml:"api_keys_flush_interval" toml:"api_keys_flush_interval"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool        `yaml:"enabled" toml:"enabled"`
+	Backend string      `yaml:"backend" toml:"backend"`
+	APIKey  LimitConfig `yaml:"api_key" toml:"api_key"`
+	User    LimitConfig `yaml:"user" toml:"user"`
+	// IP applies to every request before credentials are checked, so it
+	// throttles floods of bad credentials. Clients behind one NAT or proxy
+	// share it, so it defaults to four times the APIKey limit.
+	IP LimitConfig `yaml:"ip" toml:"ip"`
+}
+
+// LimitConfig is a token bucket: Burst requests at once, refilled at Rate
+// requests per second. A zero Rate leaves that kind of client unlimited.
+type LimitConfig struct {
+	Rate  float64 `yaml:"rate" toml:"rate"`
+	Burst int     `yaml:"burst" toml:"burst"`
+}
+
+type PagingConfig struct {
+	DefaultLimit int `yaml:"default_limit" toml:"default_limit"`
+	MaxLimit     int `yaml:"max_limit" toml:"max_limit"`
+	// CursorSecret signs pagination cursors. When empty a random key is
+	// used and cursors stop working across restarts and replicas.
+	CursorSecret string `yaml:"cursor_secret" toml:"cursor_secret"`
+}
+
+type OpenAPIConfig struct {
+	// ValidateRequests rejects requests whose parameters or body do not
+	// match the published OpenAPI document.
+	ValidateRequests bool `yaml:"validate_requests" toml:"validate_requests"`
+	// ValidateResponses turns responses that drift from the document into
+	// 500s. It is refused in release mode.
+	ValidateResponses bool `yaml:"validate_responses" toml:"validate_responses"`
+	// MaxBodyBytes caps the request bodies validation reads; larger ones
+	// get a 413. Streamed bodies such as bulk imports are not read.
+	MaxBodyBytes int `yaml:"max_body_bytes" toml:"max_body_bytes"`
+}
+
+type FeatureConfig struct {
+	Persons      bool `yaml:"persons" toml:"persons"`
+	LegacyPerson bool `yaml:"legacy_person" toml:"legacy_person"`
+	Metrics      bool `yaml:"metrics" toml:"metrics"`
+}
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Mode:              "http",
+			Address:           ":9000",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+			TLS: TLSConfig{
+				ClientAuth:     "require",
+				ReloadInterval: 30 * time.Second,
+			},
+		},
+		Gin: GinConfig{
+			Mode: "debug",
+		},
+		Log: LogConfig{
+			Format: "json",
+			Level:  "info",
+		},
+		Store: StoreConfig{
+			CompactThreshold: 1000,
+		},
+		Tracing: TracingConfig{
+			Endpoint:    "http://localhost:4318/v1/traces",
+			SampleRatio: 1,
+			ServiceName: "gin-introductory-proj",
+		},
+		Auth: AuthConfig{
+			ReloadInterval:       30 * time.Second,
+			APIKeysFlushInterval: time.Minute,
+			ClockSkew:            time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			Backend: "memory",
+			APIKey:  LimitConfig{Rate: 50, Burst: 100},
+			User:    LimitConfig{Rate: 20, Burst: 40},
+			IP:      LimitConfig{Rate: 200, Burst: 400},
+		},
+		Paging: PagingConfig{
+			DefaultLimit: 50,
+			MaxLimit:     500,
+		},
+		OpenAPI: OpenAPIConfig{
+			ValidateRequests: true,
+			MaxBodyBytes:     1 << 20,
+		},
+		Features: FeatureConfig{
+			Persons:      true,
+			LegacyPerson: true,
+			Metrics:      true,
+		},
+	}
+}
+
+// Source reports where the effective value of key (e.g. "server.address")
+// came from: "default", "file:<path>", "env:<VAR>" or "flag:-<name>".
+func (c *Config) Source(key string) string {
+	if src, ok := c.sources[key]; ok {
+		return src
+	}
+	return "default"
+}
@@ -0,0 +1,383 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Options are the command-line switches that steer loading itself rather
+// than configure the service.
+type Options struct {
+	File        string
+	PrintConfig bool
+}
+
+// field is one leaf of Config addressed by its dotted key.
+type field struct {
+	key   string
+	value reflect.Value
+}
+
+func fields(c *Config) []field {
+	var out []field
+	var walk func(prefix string, v reflect.Value)
+	walk = func(prefix string, v reflect.Value) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			key := sf.Tag.Get("yaml")
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Struct {
+				walk(key, fv)
+				continue
+			}
+			out = append(out, field{key: key, value: fv})
+		}
+	}
+	walk("", reflect.ValueOf(c).Elem())
+	return out
+}
+
+func envName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+func flagName(key string) string {
+	return strings.ReplaceAll(key, "_", "-")
+}
+
+// Load builds the effective configuration from defaults, then an optional
+// YAML or TOML file, then GIN_INTRO_* environment variables, then flags in
+// args, later layers overriding earlier ones.
+func Load(name string, args []string) (*Config, Options, error) {
+	return LoadWithEnv(name, args, os.LookupEnv)
+}
+
+func LoadWithEnv(name string, args []string, lookupEnv func(string) (string, bool)) (*Config, Options, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	l := NewLoader(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, Options{}, err
+	}
+	return l.Load(lookupEnv)
+}
+
+// Loader defines -config, -print-config and one flag per key on a FlagSet
+// that the caller parses, so commands can add flags of their own.
+type Loader struct {
+	opts       Options
+	cfg        *Config
+	leaves     []field
+	flagValues map[string]string
+	flagOrder  []string
+}
+
+func NewLoader(fs *flag.FlagSet) *Loader {
+	l := &Loader{cfg: Default(), flagValues: make(map[string]string)}
+	l.cfg.sources = make(map[string]string)
+	l.leaves = fields(l.cfg)
+	fs.StringVar(&l.opts.File, "config", "", "path to a YAML or TOML config file (env "+EnvPrefix+"CONFIG)")
+	fs.BoolVar(&l.opts.PrintConfig, "print-config", false, "print the effective configuration and where each value came from, then exit")
+	for _, f := range l.leaves {
+		key := f.key
+		fs.Func(flagName(key), fmt.Sprintf("sets %s (env %s)", key, envName(key)), func(s string) error {
+			if _, seen := l.flagValues[key]; !seen {
+				l.flagOrder = append(l.flagOrder, key)
+			}
+			l.flagValues[key] = s
+			return nil
+		})
+	}
+	return l
+}
+
+// Load layers the file, the environment and the parsed flags over the
+// defaults and validates the result. Call it once, after parsing.
+func (l *Loader) Load(lookupEnv func(string) (string, bool)) (*Config, Options, error) {
+	cfg, opts := l.cfg, l.opts
+	if opts.File == "" {
+		opts.File, _ = lookupEnv(EnvPrefix + "CONFIG")
+	}
+	if opts.File != "" {
+		if err := cfg.loadFile(opts.File, l.leaves); err != nil {
+			return nil, opts, err
+		}
+	}
+
+	for _, f := range l.leaves {
+		name := envName(f.key)
+		if raw, ok := lookupEnv(name); ok {
+			if err := setString(f.value, raw); err != nil {
+				return nil, opts, fmt.Errorf("config: %s: %w", name, err)
+			}
+			cfg.sources[f.key] = "env:" + name
+		}
+	}
+
+	byKey := make(map[string]field, len(l.leaves))
+	for _, f := range l.leaves {
+		byKey[f.key] = f
+	}
+	for _, key := range l.flagOrder {
+		if err := setString(byKey[key].value, l.flagValues[key]); err != nil {
+			return nil, opts, fmt.Errorf("config: -%s: %w", flagName(key), err)
+		}
+		cfg.sources[key] = "flag:-" + flagName(key)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, opts, err
+	}
+	return cfg, opts, nil
+}
+
+func (c *Config) loadFile(path string, leaves []field) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	raw := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return fmt.Errorf("config: unsupported file extension %q", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+
+	flat := make(map[string]any)
+	flatten("", raw, flat)
+	known := make(map[string]field, len(leaves))
+	for _, f := range leaves {
+		known[f.key] = f
+	}
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		f, ok := known[key]
+		if !ok {
+			return fmt.Errorf("config: %s: unknown key %q", path, key)
+		}
+		if err := setAny(f.value, flat[key]); err != nil {
+			return fmt.Errorf("config: %s: %s: %w", path, key, err)
+		}
+		c.sources[key] = "file:" + path
+	}
+	return nil
+}
+
+func flatten(prefix string, in map[string]any, out map[string]any) {
+	for k, v := range in {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]any); ok {
+			flatten(key, nested, out)
+			continue
+		}
+		out[key] = v
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setAny assigns a decoded YAML/TOML value, routing scalars through
+// setString so both file formats and the environment share one parser.
+func setAny(dst reflect.Value, v any) error {
+	if list, ok := v.([]any); ok {
+		if dst.Kind() != reflect.Slice {
+			return errors.New("unexpected list")
+		}
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = fmt.Sprint(item)
+		}
+		dst.Set(reflect.ValueOf(items))
+		return nil
+	}
+	return setString(dst, fmt.Sprint(v))
+}
+
+func setString(dst reflect.Value, s string) error {
+	switch {
+	case dst.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		dst.SetInt(int64(d))
+	case dst.Kind() == reflect.String:
+		dst.SetString(s)
+	case dst.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		dst.SetBool(b)
+	case dst.Kind() == reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		dst.SetInt(int64(n))
+	case dst.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(f)
+	case dst.Kind() == reflect.Slice && dst.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		dst.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", dst.Type())
+	}
+	return nil
+}
+
+func (c *Config) Validate() error {
+	switch c.Gin.Mode {
+	case gin.DebugMode, gin.ReleaseMode, gin.TestMode:
+	default:
+		return fmt.Errorf("config: gin.mode must be one of debug, release, test; got %q", c.Gin.Mode)
+	}
+	switch c.Log.Format {
+	case "text", "json":
+	default:
+		return fmt.Errorf("config: log.format must be text or json; got %q", c.Log.Format)
+	}
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("config: log.level must be one of debug, info, warn, error; got %q", c.Log.Level)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("config: tracing.sample_ratio must be within [0, 1]; got %v", c.Tracing.SampleRatio)
+	}
+	if c.Tracing.Enabled && c.Tracing.Endpoint == "" {
+		return errors.New("config: tracing.endpoint is required when tracing is enabled")
+	}
+	if c.Auth.Enabled && c.Auth.JWKSFile == "" {
+		return errors.New("config: auth.jwks_file is required when auth is enabled")
+	}
+	if c.Auth.PolicyFile != "" && !c.Auth.Enabled && !c.Auth.APIKeys {
+		return errors.New("config: auth.policy_file requires auth or auth.api_keys to be enabled")
+	}
+	if c.Auth.APIKeys && c.Auth.PolicyFile == "" {
+		return errors.New("config: auth.api_keys requires auth.policy_file to govern key management")
+	}
+	if c.RateLimit.Enabled && c.RateLimit.Backend != "memory" {
+		return fmt.Errorf("config: rate_limit.backend must be memory; got %q", c.RateLimit.Backend)
+	}
+	limits := []struct {
+		name string
+		LimitConfig
+	}{{"api_key", c.RateLimit.APIKey}, {"user", c.RateLimit.User}, {"ip", c.RateLimit.IP}}
+	for _, l := range limits {
+		name := l.name
+		if l.Rate < 0 || l.Burst < 0 {
+			return fmt.Errorf("config: rate_limit.%s rate and burst must not be negative", name)
+		}
+		if l.Rate > 0 && l.Burst == 0 {
+			return fmt.Errorf("config: rate_limit.%s.burst must be positive when a rate is set", name)
+		}
+	}
+	if c.Paging.DefaultLimit < 1 || c.Paging.MaxLimit < c.Paging.DefaultLimit {
+		return errors.New("config: paging.default_limit must be positive and no greater than paging.max_limit")
+	}
+	if c.OpenAPI.ValidateResponses && c.Gin.Mode == gin.ReleaseMode {
+		return errors.New("config: openapi.validate_responses is only allowed outside gin release mode")
+	}
+	if c.OpenAPI.MaxBodyBytes < 1 {
+		return errors.New("config: openapi.max_body_bytes must be positive")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		return errors.New("config: server.shutdown_timeout must be positive")
+	}
+	if c.Server.Address == "" {
+		return errors.New("config: server.address must not be empty")
+	}
+	tlsFiles := c.Server.TLS.CertFile != "" || c.Server.TLS.KeyFile != "" || c.Server.TLS.ClientCAFile != ""
+	switch c.Server.Mode {
+	case "http", "h2c":
+		if tlsFiles {
+			return fmt.Errorf("config: server.tls files require server.mode tls; got %q", c.Server.Mode)
+		}
+	case "tls":
+		if c.Server.TLS.CertFile == "" || c.Server.TLS.KeyFile == "" {
+			return errors.New("config: server.tls.cert_file and server.tls.key_file are required in tls mode")
+		}
+	default:
+		return fmt.Errorf("config: server.mode must be one of http, h2c, tls; got %q", c.Server.Mode)
+	}
+	switch c.Server.TLS.ClientAuth {
+	case "require", "optional":
+	default:
+		return fmt.Errorf("config: server.tls.client_auth must be require or optional; got %q", c.Server.TLS.ClientAuth)
+	}
+	return nil
+}
+
+// Print writes every effective value and its source, one key per line.
+func (c *Config) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	for _, f := range fields(c) {
+		value := formatValue(f.value)
+		if strings.HasSuffix(f.key, "secret") && f.value.String() != "" {
+			value = `"<redacted>"`
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", f.key, value, c.Source(f.key))
+	}
+	return tw.Flush()
+}
+
+func formatValue(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strconv.Quote(strings.Join(items, ","))
+	case v.Kind() == reflect.String:
+		return strconv.Quote(v.String())
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
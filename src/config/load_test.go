@@ -0,0 +1,247 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// env is a fake environment for LoadWithEnv.
+type env map[string]string
+
+func (e env) lookup(name string) (string, bool) {
+	v, ok := e[name]
+	return v, ok
+}
+
+func writeConfig(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLayering(t *testing.T) {
+	yamlFile := writeConfig(t, "config.yaml", `
+server:
+  address: ":1001"
+  read_timeout: 3s
+log:
+  level: debug
+paging:
+  max_limit: 100
+  cursor_secret: hunter2
+gin:
+  trusted_proxies: [10.0.0.0/8, 127.0.0.1]
+`)
+	tomlFile := writeConfig(t, "config.toml", `
+[server]
+address = ":1001"
+read_timeout = "3s"
+
+[log]
+level = "debug"
+
+[paging]
+max_limit = 100
+cursor_secret = "hunter2"
+
+[gin]
+trusted_proxies = ["10.0.0.0/8", "127.0.0.1"]
+`)
+
+	for _, file := range []string{yamlFile, tomlFile} {
+		t.Run(filepath.Ext(file), func(t *testing.T) {
+			environment := env{
+				"GIN_INTRO_SERVER_ADDRESS":     ":1002",
+				"GIN_INTRO_LOG_LEVEL":          "warn",
+				"GIN_INTRO_RATE_LIMIT_IP_RATE": "7",
+			}
+			args := []string{"-config", file, "-server.address", ":1003", "-log.format=text", "-log.format=json"}
+			cfg, opts, err := LoadWithEnv("test", args, environment.lookup)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opts.File != file {
+				t.Errorf("opts.File = %q, want %q", opts.File, file)
+			}
+			tests := []struct {
+				key    string
+				got    any
+				want   any
+				source string
+			}{
+				{"server.address", cfg.Server.Address, ":1003", "flag:-server.address"},
+				{"log.level", cfg.Log.Level, "warn", "env:GIN_INTRO_LOG_LEVEL"},
+				{"log.format", cfg.Log.Format, "json", "flag:-log.format"},
+				{"rate_limit.ip.rate", cfg.RateLimit.IP.Rate, 7.0, "env:GIN_INTRO_RATE_LIMIT_IP_RATE"},
+				{"server.read_timeout", cfg.Server.ReadTimeout, 3 * time.Second, "file:" + file},
+				{"paging.max_limit", cfg.Paging.MaxLimit, 100, "file:" + file},
+				{"paging.default_limit", cfg.Paging.DefaultLimit, 50, "default"},
+			}
+			for _, tt := range tests {
+				if tt.got != tt.want {
+					t.Errorf("%s = %v, want %v", tt.key, tt.got, tt.want)
+				}
+				if src := cfg.Source(tt.key); src != tt.source {
+					t.Errorf("%s came from %s, want %s", tt.key, src, tt.source)
+				}
+			}
+			if want := []string{"10.0.0.0/8", "127.0.0.1"}; !slices.Equal(cfg.Gin.TrustedProxies, want) {
+				t.Errorf("gin.trusted_proxies = %v, want %v", cfg.Gin.TrustedProxies, want)
+			}
+
+			var out bytes.Buffer
+			if err := cfg.Print(&out); err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(out.String(), "hunter2") || !strings.Contains(out.String(), "<redacted>") {
+				t.Errorf("Print does not redact the cursor secret:\n%s", out.String())
+			}
+		})
+	}
+
+	t.Run("file from the environment", func(t *testing.T) {
+		cfg, opts, err := LoadWithEnv("test", nil, env{"GIN_INTRO_CONFIG": yamlFile}.lookup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts.File != yamlFile || cfg.Server.Address != ":1001" {
+			t.Errorf("file %q, address %q; want the file named by GIN_INTRO_CONFIG", opts.File, cfg.Server.Address)
+		}
+	})
+
+	t.Run("environment list", func(t *testing.T) {
+		cfg, _, err := LoadWithEnv("test", nil, env{"GIN_INTRO_GIN_TRUSTED_PROXIES": " 10.0.0.1, ,10.0.0.2 "}.lookup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"10.0.0.1", "10.0.0.2"}; !slices.Equal(cfg.Gin.TrustedProxies, want) {
+			t.Errorf("gin.trusted_proxies = %v, want %v", cfg.Gin.TrustedProxies, want)
+		}
+	})
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		data    string
+		env     env
+		args    []string
+		wantErr string
+	}{
+		{name: "unknown key", file: "c.yaml", data: "server:\n  adress: x\n", wantErr: `unknown key "server.adress"`},
+		{name: "unsupported extension", file: "c.json", data: "{}", wantErr: "unsupported file extension"},
+		{name: "malformed file", file: "c.toml", data: "[server", wantErr: "c.toml"},
+		{name: "bad file value", file: "c.yaml", data: "server:\n  read_timeout: soon\n", wantErr: "server.read_timeout"},
+		{name: "missing file", args: []string{"-config", "/nonexistent.yaml"}, wantErr: "nonexistent"},
+		{name: "bad env value", env: env{"GIN_INTRO_PAGING_MAX_LIMIT": "many"}, wantErr: "GIN_INTRO_PAGING_MAX_LIMIT"},
+		{name: "bad flag value", args: []string{"-features.persons=maybe"}, wantErr: "-features.persons"},
+		{name: "unknown flag", args: []string{"-no-such-flag"}, wantErr: "no-such-flag"},
+		{name: "invalid result", env: env{"GIN_INTRO_LOG_LEVEL": "loud"}, wantErr: "log.level"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeConfig(t, tt.file, tt.data)}, args...)
+			}
+			_, _, err := LoadWithEnv("test", args, tt.env.lookup)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(*Config)
+		// wantErr is a key the error must name, or empty for a valid
+		// configuration.
+		wantErr string
+	}{
+		{name: "defaults", edit: func(*Config) {}},
+		{name: "gin mode", edit: func(c *Config) { c.Gin.Mode = "fast" }, wantErr: "gin.mode"},
+		{name: "log format", edit: func(c *Config) { c.Log.Format = "xml" }, wantErr: "log.format"},
+		{name: "log level in any case", edit: func(c *Config) { c.Log.Level = "WARN" }},
+		{name: "log level", edit: func(c *Config) { c.Log.Level = "trace" }, wantErr: "log.level"},
+		{name: "sample ratio", edit: func(c *Config) { c.Tracing.SampleRatio = 1.5 }, wantErr: "tracing.sample_ratio"},
+		{name: "tracing endpoint", edit: func(c *Config) {
+			c.Tracing.Enabled = true
+			c.Tracing.Endpoint = ""
+		}, wantErr: "tracing.endpoint"},
+		{name: "auth without keys", edit: func(c *Config) { c.Auth.Enabled = true }, wantErr: "auth.jwks_file"},
+		{name: "policy without authentication", edit: func(c *Config) { c.Auth.PolicyFile = "policy.yaml" }, wantErr: "auth.policy_file"},
+		{name: "policy with JWT authentication", edit: func(c *Config) {
+			c.Auth.Enabled = true
+			c.Auth.JWKSFile = "jwks.json"
+			c.Auth.PolicyFile = "policy.yaml"
+		}},
+		{name: "policy with API keys alone", edit: func(c *Config) {
+			c.Auth.APIKeys = true
+			c.Auth.PolicyFile = "policy.yaml"
+		}},
+		{name: "API keys without a policy", edit: func(c *Config) { c.Auth.APIKeys = true }, wantErr: "auth.api_keys"},
+		{name: "rate limit backend", edit: func(c *Config) {
+			c.RateLimit.Enabled = true
+			c.RateLimit.Backend = "redis"
+		}, wantErr: "rate_limit.backend"},
+		{name: "negative rate", edit: func(c *Config) { c.RateLimit.User.Rate = -1 }, wantErr: "rate_limit.user"},
+		{name: "rate without burst", edit: func(c *Config) { c.RateLimit.IP.Burst = 0 }, wantErr: "rate_limit.ip.burst"},
+		{name: "no rate limit for a principal", edit: func(c *Config) { c.RateLimit.APIKey = LimitConfig{} }},
+		{name: "default limit above max", edit: func(c *Config) { c.Paging.DefaultLimit = c.Paging.MaxLimit + 1 }, wantErr: "paging.default_limit"},
+		{name: "zero default limit", edit: func(c *Config) { c.Paging.DefaultLimit = 0 }, wantErr: "paging.default_limit"},
+		{name: "response validation in release mode", edit: func(c *Config) {
+			c.Gin.Mode = "release"
+			c.OpenAPI.ValidateResponses = true
+		}, wantErr: "openapi.validate_responses"},
+		{name: "response validation in debug mode", edit: func(c *Config) { c.OpenAPI.ValidateResponses = true }},
+		{name: "body limit", edit: func(c *Config) { c.OpenAPI.MaxBodyBytes = 0 }, wantErr: "openapi.max_body_bytes"},
+		{name: "shutdown timeout", edit: func(c *Config) { c.Server.ShutdownTimeout = 0 }, wantErr: "server.shutdown_timeout"},
+		{name: "address", edit: func(c *Config) { c.Server.Address = "" }, wantErr: "server.address"},
+		{name: "tls files without tls", edit: func(c *Config) { c.Server.TLS.CertFile = "cert.pem" }, wantErr: "server.mode tls"},
+		{name: "tls without a certificate", edit: func(c *Config) { c.Server.Mode = "tls" }, wantErr: "server.tls.cert_file"},
+		{name: "tls", edit: func(c *Config) {
+			c.Server.Mode = "tls"
+			c.Server.TLS.CertFile = "cert.pem"
+			c.Server.TLS.KeyFile = "key.pem"
+			c.Server.TLS.ClientCAFile = "ca.pem"
+			c.Server.TLS.ClientAuth = "optional"
+		}},
+		{name: "server mode", edit: func(c *Config) { c.Server.Mode = "quic" }, wantErr: "server.mode"},
+		{name: "client auth", edit: func(c *Config) { c.Server.TLS.ClientAuth = "maybe" }, wantErr: "server.tls.client_auth"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.edit(cfg)
+			err := cfg.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Validate() = %v, want an error naming %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultLimits(t *testing.T) {
+	// Clients behind one NAT share an IP bucket, so it must hold well
+	// above what a single principal may use.
+	rl := Default().RateLimit
+	for _, principal := range []LimitConfig{rl.APIKey, rl.User} {
+		if rl.IP.Rate < 2*principal.Rate || rl.IP.Burst < 2*principal.Burst {
+			t.Errorf("ip limit %+v is not well above the principal limit %+v", rl.IP, principal)
+		}
+	}
+}
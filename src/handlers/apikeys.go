@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/apikeys"
+	"github.com/faishalshidqi/gin-introductory-proj/src/auth"
+	"github.com/faishalshidqi/gin-introductory-proj/src/logging"
+	"github.com/faishalshidqi/gin-introductory-proj/src/problem"
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeysHandler struct {
+	keys *apikeys.Store
+}
+
+func NewAPIKeysHandler(keys *apikeys.Store) *APIKeysHandler {
+	return &APIKeysHandler{keys: keys}
+}
+
+type apiKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// mintedAPIKey is only ever returned by mint and rotate: the plaintext key
+// cannot be retrieved again.
+type mintedAPIKey struct {
+	apikeys.Key
+	Plaintext string `json:"key"`
+}
+
+func (h *APIKeysHandler) ListAPIKeysHandler(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"apiKeys": h.keys.List()})
+}
+
+func (h *APIKeysHandler) NewAPIKeyHandler(ctx *gin.Context) {
+	var req apiKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if !valid(ctx, &req) {
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		problem.Write(ctx, problem.New(http.StatusBadRequest, "expiresAt must be in the future"))
+		return
+	}
+	var createdBy string
+	if principal := auth.PrincipalFromContext(ctx); principal != nil {
+		createdBy = principal.Subject
+	}
+	key, token, err := h.keys.Mint(req.Name, req.Scopes, req.ExpiresAt, createdBy)
+	if err != nil {
+		apiKeyError(ctx, err)
+		return
+	}
+	logging.FromContext(ctx).Info("api key minted", "api_key_id", key.ID, "scopes", key.Scopes)
+	ctx.Header("Location", "/admin/api-keys/"+key.ID)
+	ctx.JSON(http.StatusCreated, mintedAPIKey{Key: key, Plaintext: token})
+}
+
+func (h *APIKeysHandler) GetAPIKeyHandler(ctx *gin.Context) {
+	key, err := h.keys.Get(ctx.Param("id"))
+	if err != nil {
+		apiKeyError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, key)
+}
+
+func (h *APIKeysHandler) RotateAPIKeyHandler(ctx *gin.Context) {
+	key, token, err := h.keys.Rotate(ctx.Param("id"))
+	if err != nil {
+		apiKeyError(ctx, err)
+		return
+	}
+	logging.FromContext(ctx).Info("api key rotated", "api_key_id", key.ID)
+	ctx.JSON(http.StatusOK, mintedAPIKey{Key: key, Plaintext: token})
+}
+
+func (h *APIKeysHandler) RevokeAPIKeyHandler(ctx *gin.Context) {
+	key, err := h.keys.Revoke(ctx.Param("id"))
+	if err != nil {
+		apiKeyError(ctx, err)
+		return
+	}
+	logging.FromContext(ctx).Info("api key revoked", "api_key_id", key.ID)
+	ctx.JSON(http.StatusOK, key)
+}
+
+func apiKeyError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, apikeys.ErrNotFound):
+		renderError(ctx, http.StatusNotFound, err)
+	case errors.Is(err, apikeys.ErrRevoked):
+		renderError(ctx, http.StatusConflict, err)
+	case errors.Is(err, apikeys.ErrInvalidScope):
+		renderError(ctx, http.StatusBadRequest, err)
+	default:
+		logging.FromContext(ctx).Error("api key operation failed", "error", err)
+		renderError(ctx, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/apikeys"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAPIKeysHandlers(t *testing.T) {
+	keys, err := apikeys.NewStore("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer keys.Close()
+	h := NewAPIKeysHandler(keys)
+	router := gin.New()
+	router.GET("/keys", h.ListAPIKeysHandler)
+	router.POST("/keys", h.NewAPIKeyHandler)
+	router.GET("/keys/:id", h.GetAPIKeyHandler)
+	router.POST("/keys/:id/rotate", h.RotateAPIKeyHandler)
+	router.DELETE("/keys/:id", h.RevokeAPIKeyHandler)
+	do := func(t *testing.T, method, path, body string) (int, mintedAPIKey) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var key mintedAPIKey
+		if w.Code < 300 {
+			if err := json.Unmarshal(w.Body.Bytes(), &key); err != nil {
+				t.Fatalf("%s %s: %v: %s", method, path, err, w.Body)
+			}
+		}
+		return w.Code, key
+	}
+
+	code, minted := do(t, "POST", "/keys", `{"name":"batch","scopes":["persons:read"]}`)
+	if code != http.StatusCreated || minted.Plaintext == "" {
+		t.Fatalf("mint = %d, %+v", code, minted)
+	}
+	if _, err := keys.Verify(minted.Plaintext); err != nil {
+		t.Errorf("minted key does not verify: %v", err)
+	}
+	code, got := do(t, "GET", "/keys/"+minted.ID, "")
+	if code != http.StatusOK || got.ID != minted.ID || got.Plaintext != "" {
+		t.Errorf("get = %d, %+v; want the key without its plaintext", code, got)
+	}
+	code, rotated := do(t, "POST", "/keys/"+minted.ID+"/rotate", "")
+	if code != http.StatusOK || rotated.Plaintext == "" || rotated.Plaintext == minted.Plaintext {
+		t.Errorf("rotate = %d, %+v", code, rotated)
+	}
+	code, revoked := do(t, "DELETE", "/keys/"+minted.ID, "")
+	if code != http.StatusOK || revoked.RevokedAt == nil {
+		t.Errorf("revoke = %d, %+v", code, revoked)
+	}
+
+	tests := []struct {
+		name, method, path, body string
+		want                     int
+	}{
+		{"mint without scopes", "POST", "/keys", `{"name":"batch","scopes":[]}`, http.StatusBadRequest},
+		{"mint with a bad scope", "POST", "/keys", `{"name":"batch","scopes":["everything"]}`, http.StatusBadRequest},
+		{"mint already expired", "POST", "/keys", `{"name":"batch","scopes":["persons:read"],"expiresAt":"2000-01-01T00:00:00Z"}`, http.StatusBadRequest},
+		{"get unknown", "GET", "/keys/nope", "", http.StatusNotFound},
+		{"rotate revoked", "POST", "/keys/" + minted.ID + "/rotate", "", http.StatusConflict},
+		{"revoke unknown", "DELETE", "/keys/nope", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _ := do(t, tt.method, tt.path, tt.body); code != tt.want {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, code, tt.want)
+			}
+		})
+	}
+}
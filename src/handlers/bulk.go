@@ -0,0 +1,477 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/auth"
+	"github.com/faishalshidqi/gin-introductory-proj/src/logging"
+	"github.com/faishalshidqi/gin-introductory-proj/src/problem"
+	"github.com/faishalshidqi/gin-introductory-proj/src/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	MIMECSV    = "text/csv"
+	MIMENDJSON = "application/x-ndjson"
+
+	// maxReportedErrors bounds the import report so a file full of bad
+	// rows cannot exhaust memory; the counts stay exact.
+	maxReportedErrors = 1000
+	// exportPageSize persons are read from the store at a time; each
+	// page is flushed to the client before the next is read.
+	exportPageSize = 1000
+	// maxNDJSONLine is the longest accepted NDJSON record.
+	maxNDJSONLine = 1 << 20
+)
+
+// bulkFormat is a streaming person file format for import and export.
+type bulkFormat struct {
+	name      string
+	mimes     []string
+	extension string
+	reader    func(io.Reader) personReader
+	writer    func(io.Writer) personWriter
+}
+
+var bulkFormats = []bulkFormat{
+	{"csv", []string{MIMECSV}, "csv", newCSVPersonReader, newCSVPersonWriter},
+	{"ndjson", []string{MIMENDJSON, "application/ndjson"}, "ndjson", newNDJSONPersonReader, newNDJSONPersonWriter},
+	{"xml", []string{binding.MIMEXML, binding.MIMEXML2}, "xml", newXMLPersonReader, newXMLPersonWriter},
+}
+
+// personReader yields one record at a time. Next returns io.EOF at the end,
+// a *recordError for a bad record the reader can continue past, and any
+// other error when the stream cannot be read further.
+type personReader interface {
+	Next() (Person, int, error)
+}
+
+type personWriter interface {
+	Write(Person) error
+	Close() error
+}
+
+type recordError struct {
+	err error
+}
+
+func (e *recordError) Error() string { return e.err.Error() }
+
+type importReport struct {
+	XMLName         xml.Name      `json:"-" xml:"importReport" yaml:"-" toml:"-"`
+	DryRun          bool          `json:"dryRun" xml:"dryRun,attr" yaml:"dryRun" toml:"dryRun"`
+	Rows            int           `json:"rows" xml:"rows,attr" yaml:"rows" toml:"rows"`
+	Succeeded       int           `json:"succeeded" xml:"succeeded,attr" yaml:"succeeded" toml:"succeeded"`
+	Failed          int           `json:"failed" xml:"failed,attr" yaml:"failed" toml:"failed"`
+	Errors          []importError `json:"errors,omitempty" xml:"error" yaml:"errors,omitempty" toml:"errors,omitempty"`
+	ErrorsTruncated bool          `json:"errorsTruncated,omitempty" xml:"errorsTruncated,attr,omitempty" yaml:"errorsTruncated,omitempty" toml:"errorsTruncated,omitempty"`
+}
+
+// importError describes one failed row. Row counts records from 1; Line
+// is where the record starts in the file.
+type importError struct {
+	Row           int                    `json:"row" xml:"row,attr" yaml:"row" toml:"row"`
+	Line          int                    `json:"line,omitempty" xml:"line,attr,omitempty" yaml:"line,omitempty" toml:"line,omitempty"`
+	Detail        string                 `json:"detail,omitempty" xml:"detail,omitempty" yaml:"detail,omitempty" toml:"detail,omitempty"`
+	InvalidParams []problem.InvalidParam `json:"invalid-params,omitempty" xml:"invalidParam" yaml:"invalid-params,omitempty" toml:"invalid-params,omitempty"`
+}
+
+func (r *importReport) fail(e importError) {
+	r.Failed++
+	if len(r.Errors) < maxReportedErrors {
+		r.Errors = append(r.Errors, e)
+	} else {
+		r.ErrorsTruncated = true
+	}
+}
+
+// ImportPersonsHandler creates one person per record of a CSV, NDJSON or
+// XML body, streaming it so memory use does not grow with the file. Rows
+// fail independently and are listed in the report; with ?dryRun=true rows
+// are only validated.
+func (h *PersonsHandler) ImportPersonsHandler(ctx *gin.Context) {
+	format, ok := bulkFormatFor(ctx.ContentType())
+	if !ok {
+		problem.Write(ctx, problem.New(http.StatusUnsupportedMediaType, "import accepts CSV, NDJSON or XML").
+			With("supported", bulkMIMEs()))
+		return
+	}
+	dryRun, err := strconv.ParseBool(ctx.DefaultQuery("dryRun", "false"))
+	if err != nil {
+		invalidQuery(ctx, []problem.InvalidParam{{Name: "dryRun", Reason: "must be true or false"}})
+		return
+	}
+	var createdBy string
+	if principal := auth.PrincipalFromContext(ctx); principal != nil {
+		createdBy = principal.Subject
+	}
+	lang := requestLanguage(ctx)
+
+	report := importReport{DryRun: dryRun}
+	records := format.reader(ctx.Request.Body)
+	for {
+		person, line, err := records.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var recErr *recordError
+		if err != nil && !errors.As(err, &recErr) {
+			if report.Rows == 0 {
+				renderError(ctx, http.StatusBadRequest, err)
+				return
+			}
+			// The stream is unreadable from here; report what was done.
+			report.fail(importError{Row: report.Rows + 1, Line: line, Detail: "import stopped: " + err.Error()})
+			break
+		}
+		report.Rows++
+		row := importError{Row: report.Rows, Line: line}
+		if recErr != nil {
+			row.Detail = recErr.Error()
+			report.fail(row)
+			continue
+		}
+
+		person = Person{
+			FirstName: validation.NormalizeName(person.FirstName),
+			LastName:  validation.NormalizeName(person.LastName),
+			CreatedBy: createdBy,
+		}
+		if params := validation.Struct(&person, lang); len(params) > 0 {
+			row.InvalidParams = params
+			report.fail(row)
+			continue
+		}
+		if !dryRun {
+			if _, err := h.store.Create(ctx, person); err != nil {
+				logging.FromContext(ctx).Error("import: store operation failed", "row", row.Row, "error", err)
+				row.Detail = err.Error()
+				report.fail(row)
+				continue
+			}
+		}
+		report.Succeeded++
+	}
+	logging.FromContext(ctx).Info("persons imported",
+		"format", format.name, "dry_run", dryRun, "rows", report.Rows, "succeeded", report.Succeeded, "failed", report.Failed)
+	respond(ctx, http.StatusOK, report, binding.MIMEJSON)
+}
+
+// ExportPersonsHandler streams every person as CSV, NDJSON or XML, chosen
+// by ?format= or the Accept header, defaulting to CSV.
+func (h *PersonsHandler) ExportPersonsHandler(ctx *gin.Context) {
+	ctx.Writer.Header().Add("Vary", "Accept")
+	format, ok := negotiateBulk(ctx)
+	if !ok {
+		problem.Write(ctx, problem.New(http.StatusNotAcceptable, "export offers CSV, NDJSON or XML").
+			With("supported", bulkMIMEs()))
+		return
+	}
+	// The first page is read before any header is written so that a
+	// failing store still gets a problem response.
+	persons, err := h.store.ListPage(ctx, Person{}, exportPageSize)
+	if err != nil {
+		storeError(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Type", format.mimes[0]+"; charset=utf-8")
+	ctx.Header("Content-Disposition", `attachment; filename="persons.`+format.extension+`"`)
+	ctx.Status(http.StatusOK)
+	out := format.writer(ctx.Writer)
+	rows := 0
+	for len(persons) > 0 {
+		for _, p := range persons {
+			rows++
+			if err := out.Write(p); err != nil {
+				// Headers are gone; all that is left is to stop and log.
+				logging.FromContext(ctx).Warn("export aborted", "row", rows, "error", err)
+				return
+			}
+		}
+		ctx.Writer.Flush()
+		if len(persons) < exportPageSize {
+			break
+		}
+		if persons, err = h.store.ListPage(ctx, persons[len(persons)-1], exportPageSize); err != nil {
+			logging.FromContext(ctx).Warn("export aborted", "row", rows, "error", err)
+			return
+		}
+	}
+	if err := out.Close(); err != nil {
+		logging.FromContext(ctx).Warn("export aborted", "error", err)
+	}
+}
+
+func bulkFormatFor(mimeType string) (bulkFormat, bool) {
+	for _, f := range bulkFormats {
+		if slices.Contains(f.mimes, mimeType) {
+			return f, true
+		}
+	}
+	return bulkFormat{}, false
+}
+
+func bulkMIMEs() []string {
+	var mimes []string
+	for _, f := range bulkFormats {
+		mimes = append(mimes, f.mimes...)
+	}
+	return mimes
+}
+
+func negotiateBulk(ctx *gin.Context) (bulkFormat, bool) {
+	if name := ctx.Query("format"); name != "" {
+		for _, f := range bulkFormats {
+			if f.name == name {
+				return f, true
+			}
+		}
+		return bulkFormat{}, false
+	}
+	header := ctx.GetHeader("Accept")
+	if strings.TrimSpace(header) == "" {
+		return bulkFormats[0], true
+	}
+	ranges := parseAcceptHeader(header)
+	chosen, bestQ := -1, 0.0
+	for i, f := range bulkFormats {
+		for _, m := range f.mimes {
+			if q := quality(ranges, m); q > bestQ {
+				chosen, bestQ = i, q
+			}
+		}
+	}
+	if chosen < 0 {
+		return bulkFormat{}, false
+	}
+	return bulkFormats[chosen], true
+}
+
+// csvColumns are the export columns. An import file needs firstName and
+// lastName; the remaining columns are accepted so exports re-import, but
+// the store assigns them afresh.
+var csvColumns = []string{"id", "firstName", "lastName", "createdAt", "createdBy", "updatedAt", "version"}
+
+type csvPersonReader struct {
+	r      *csv.Reader
+	header map[string]int
+	err    error
+}
+
+func newCSVPersonReader(r io.Reader) personReader {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	return &csvPersonReader{r: cr}
+}
+
+func (c *csvPersonReader) readHeader() error {
+	header, err := c.r.Read()
+	if errors.Is(err, io.EOF) {
+		return errors.New("csv: missing header row")
+	}
+	if err != nil {
+		return err
+	}
+	c.header = make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.TrimSpace(name)
+		if !slices.Contains(csvColumns, name) {
+			return fmt.Errorf("csv: unknown column %q", name)
+		}
+		c.header[name] = i
+	}
+	for _, required := range []string{"firstName", "lastName"} {
+		if _, ok := c.header[required]; !ok {
+			return fmt.Errorf("csv: missing column %q", required)
+		}
+	}
+	return nil
+}
+
+func (c *csvPersonReader) Next() (Person, int, error) {
+	if c.header == nil {
+		if err := c.readHeader(); err != nil {
+			return Person{}, 1, err
+		}
+	}
+	record, err := c.r.Read()
+	if err != nil {
+		// The reader resumes on the next line after a parse error.
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return Person{}, parseErr.StartLine, &recordError{err}
+		}
+		return Person{}, 0, err
+	}
+	line, _ := c.r.FieldPos(0)
+	return Person{
+		FirstName: record[c.header["firstName"]],
+		LastName:  record[c.header["lastName"]],
+	}, line, nil
+}
+
+type csvPersonWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func newCSVPersonWriter(w io.Writer) personWriter {
+	return &csvPersonWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvPersonWriter) Write(p Person) error {
+	if !c.header {
+		c.header = true
+		if err := c.w.Write(csvColumns); err != nil {
+			return err
+		}
+	}
+	return c.w.Write([]string{
+		p.ID, p.FirstName, p.LastName, p.CreatedAt.Format(time.RFC3339Nano), p.CreatedBy,
+		p.UpdatedAt.Format(time.RFC3339Nano), strconv.FormatInt(p.Version, 10),
+	})
+}
+
+func (c *csvPersonWriter) Close() error {
+	if !c.header {
+		c.header = true
+		c.w.Write(csvColumns)
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonPersonReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func newNDJSONPersonReader(r io.Reader) personReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+	return &ndjsonPersonReader{s: s}
+}
+
+func (n *ndjsonPersonReader) Next() (Person, int, error) {
+	for n.s.Scan() {
+		n.line++
+		data := n.s.Bytes()
+		if len(strings.TrimSpace(string(data))) == 0 {
+			continue
+		}
+		var p Person
+		if err := json.Unmarshal(data, &p); err != nil {
+			return Person{}, n.line, &recordError{err}
+		}
+		return p, n.line, nil
+	}
+	if err := n.s.Err(); err != nil {
+		return Person{}, n.line + 1, err
+	}
+	return Person{}, n.line, io.EOF
+}
+
+type ndjsonPersonWriter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONPersonWriter(w io.Writer) personWriter {
+	return ndjsonPersonWriter{enc: json.NewEncoder(w)}
+}
+
+func (n ndjsonPersonWriter) Write(p Person) error { return n.enc.Encode(p) }
+func (n ndjsonPersonWriter) Close() error         { return nil }
+
+// xmlPersonReader reads <person> elements, in the Person XML mapping, from
+// anywhere in the document; a <persons> root as written by the export is
+// the usual shape.
+type xmlPersonReader struct {
+	d *xml.Decoder
+}
+
+func newXMLPersonReader(r io.Reader) personReader {
+	return &xmlPersonReader{d: xml.NewDecoder(r)}
+}
+
+func (x *xmlPersonReader) Next() (Person, int, error) {
+	for {
+		line, _ := x.d.InputPos()
+		tok, err := x.d.Token()
+		if err != nil {
+			return Person{}, line, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local == "persons" {
+			continue
+		}
+		if start.Name.Local != "person" {
+			if err := x.d.Skip(); err != nil {
+				return Person{}, line, err
+			}
+			return Person{}, line, &recordError{fmt.Errorf("unexpected element <%s>", start.Name.Local)}
+		}
+		var p Person
+		if err := x.d.DecodeElement(&p, &start); err != nil {
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				return Person{}, line, err
+			}
+			return Person{}, line, &recordError{err}
+		}
+		return p, line, nil
+	}
+}
+
+type xmlPersonWriter struct {
+	w       io.Writer
+	enc     *xml.Encoder
+	started bool
+}
+
+func newXMLPersonWriter(w io.Writer) personWriter {
+	return &xmlPersonWriter{w: w, enc: xml.NewEncoder(w)}
+}
+
+func (x *xmlPersonWriter) start() error {
+	if x.started {
+		return nil
+	}
+	x.started = true
+	_, err := io.WriteString(x.w, xml.Header+"<persons>\n")
+	return err
+}
+
+func (x *xmlPersonWriter) Write(p Person) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	if err := x.enc.Encode(p); err != nil {
+		return err
+	}
+	_, err := io.WriteString(x.w, "\n")
+	return err
+}
+
+func (x *xmlPersonWriter) Close() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(x.w, "</persons>\n")
+	return err
+}
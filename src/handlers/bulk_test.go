@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+	"github.com/faishalshidqi/gin-introductory-proj/src/store"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// pagingStore records the pages the export asks for.
+type pagingStore struct {
+	store.PersonStore
+	pages []int
+}
+
+func (s *pagingStore) List(context.Context) ([]models.Person, error) {
+	panic("export must not list the whole store")
+}
+
+func (s *pagingStore) ListPage(ctx context.Context, after models.Person, limit int) ([]models.Person, error) {
+	page, err := s.PersonStore.ListPage(ctx, after, limit)
+	s.pages = append(s.pages, len(page))
+	return page, err
+}
+
+func TestExportSpansPages(t *testing.T) {
+	ctx := context.Background()
+	mem := store.NewMemoryStore()
+	total := 2*exportPageSize + 1
+	for range total {
+		if _, err := mem.Create(ctx, models.Person{FirstName: "Ada", LastName: "Lovelace"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, _ := mem.List(ctx)
+	s := &pagingStore{PersonStore: mem}
+	router := gin.New()
+	router.GET("/export", NewPersonsHandler(s, Paging{}).ExportPersonsHandler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export?format=ndjson", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var got []string
+	lines := bufio.NewScanner(w.Body)
+	for lines.Scan() {
+		var p Person
+		if err := json.Unmarshal(lines.Bytes(), &p); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, p.ID)
+	}
+	if len(got) != total {
+		t.Fatalf("exported %d persons, want %d", len(got), total)
+	}
+	for i := range want {
+		if got[i] != want[i].ID {
+			t.Fatalf("person %d = %s, want %s", i, got[i], want[i].ID)
+		}
+	}
+	if wantPages := []int{exportPageSize, exportPageSize, 1}; !slices.Equal(s.pages, wantPages) {
+		t.Errorf("pages = %v, want %v", s.pages, wantPages)
+	}
+}
+
+// newBulkRouter serves export and import of s.
+func newBulkRouter(s store.PersonStore) *gin.Engine {
+	h := NewPersonsHandler(s, Paging{})
+	router := gin.New()
+	router.GET("/export", h.ExportPersonsHandler)
+	router.POST("/import", h.ImportPersonsHandler)
+	return router
+}
+
+// importBody posts body as mimeType and decodes the report.
+func importBody(t *testing.T, router *gin.Engine, mimeType, query, body string) importReport {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/import"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", mimeType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("import status = %d: %s", w.Code, w.Body)
+	}
+	var report importReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func TestBulkRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	names := [][2]string{
+		{"Ada", "Lovelace"},
+		{"Seán", "O'Brien"},
+		{"Jean-Luc", "van der Berg"},
+		{"Zoë", "Ångström"},
+		{"明", "李"},
+	}
+	source := store.NewMemoryStore()
+	for _, n := range names {
+		if _, err := source.Create(ctx, models.Person{FirstName: n[0], LastName: n[1], CreatedBy: "alice"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, format := range bulkFormats {
+		t.Run(format.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newBulkRouter(source).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export?format="+format.name, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("export status = %d: %s", w.Code, w.Body)
+			}
+			if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, format.mimes[0]) {
+				t.Errorf("Content-Type = %q, want %s", got, format.mimes[0])
+			}
+
+			dest := store.NewMemoryStore()
+			report := importBody(t, newBulkRouter(dest), format.mimes[0], "", w.Body.String())
+			if report.Rows != len(names) || report.Succeeded != len(names) || report.Failed != 0 {
+				t.Fatalf("report = %+v, want %d clean rows", report, len(names))
+			}
+			imported, err := dest.List(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(imported) != len(names) {
+				t.Fatalf("imported %d persons, want %d", len(imported), len(names))
+			}
+			for i, p := range imported {
+				if p.FirstName != names[i][0] || p.LastName != names[i][1] {
+					t.Errorf("person %d = %s %s, want %s %s", i, p.FirstName, p.LastName, names[i][0], names[i][1])
+				}
+				// The import creates persons afresh under its own principal.
+				if p.CreatedBy != "" || p.Version != 1 {
+					t.Errorf("person %d kept createdBy %q, version %d from the file", i, p.CreatedBy, p.Version)
+				}
+			}
+		})
+	}
+}
+
+func TestImportReportsRowErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		mimeType string
+		body     string
+		// want lists the failed rows as row:line.
+		want          []string
+		wantSucceeded int
+	}{
+		{
+			name:     "csv",
+			mimeType: MIMECSV,
+			body:     "firstName,lastName\nAda,Lovelace\nGrace,\n\"Alan,Turing\n",
+			want:     []string{"2:3", "3:4"}, wantSucceeded: 1,
+		},
+		{
+			name:     "ndjson",
+			mimeType: MIMENDJSON,
+			body:     `{"firstName":"Ada","lastName":"Lovelace"}` + "\n\n{not json}\n" + `{"firstName":"R2-D2","lastName":"Droid"}` + "\n",
+			want:     []string{"2:3", "3:4"}, wantSucceeded: 1,
+		},
+		{
+			name:     "xml",
+			mimeType: binding.MIMEXML,
+			body:     "<persons>\n<person firstName=\"Ada\" lastName=\"Lovelace\"/>\n<robot/>\n<person firstName=\"Grace\"/>\n</persons>\n",
+			want:     []string{"2:3", "3:4"}, wantSucceeded: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := importBody(t, newBulkRouter(store.NewMemoryStore()), tt.mimeType, "", tt.body)
+			var got []string
+			for _, e := range report.Errors {
+				if e.Detail == "" && len(e.InvalidParams) == 0 {
+					t.Errorf("row %d failed without a reason", e.Row)
+				}
+				got = append(got, strconv.Itoa(e.Row)+":"+strconv.Itoa(e.Line))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("failed rows = %v, want %v (report %+v)", got, tt.want, report)
+			}
+			if report.Succeeded != tt.wantSucceeded || report.Failed != len(tt.want) || report.Rows != tt.wantSucceeded+len(tt.want) {
+				t.Errorf("report = %+v", report)
+			}
+		})
+	}
+}
+
+func TestImportCapsReportedErrors(t *testing.T) {
+	const bad = maxReportedErrors + 500
+	var body strings.Builder
+	body.WriteString("firstName,lastName\n")
+	for range bad {
+		body.WriteString("Ada,\n")
+	}
+	body.WriteString("Grace,Hopper\nAlan,Turing\n")
+
+	tests := []struct {
+		name   string
+		dryRun bool
+		stored int
+	}{
+		{"import", false, 2},
+		{"dry run", true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			report := importBody(t, newBulkRouter(s), MIMECSV, "?dryRun="+strconv.FormatBool(tt.dryRun), body.String())
+			if report.Rows != bad+2 || report.Failed != bad || report.Succeeded != 2 || report.DryRun != tt.dryRun {
+				t.Errorf("report counts = rows %d, failed %d, succeeded %d, dry run %v", report.Rows, report.Failed, report.Succeeded, report.DryRun)
+			}
+			if len(report.Errors) != maxReportedErrors || !report.ErrorsTruncated {
+				t.Errorf("reported %d errors, truncated %v; want %d, true", len(report.Errors), report.ErrorsTruncated, maxReportedErrors)
+			}
+			if last := report.Errors[len(report.Errors)-1]; last.Row != maxReportedErrors {
+				t.Errorf("last reported row = %d, want the first %d failures", last.Row, maxReportedErrors)
+			}
+			persons, err := s.List(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(persons) != tt.stored {
+				t.Errorf("stored %d persons, want %d", len(persons), tt.stored)
+			}
+		})
+	}
+
+	// Exactly at the cap nothing is truncated.
+	body.Reset()
+	body.WriteString("firstName,lastName\n")
+	for range maxReportedErrors {
+		body.WriteString("Ada,\n")
+	}
+	report := importBody(t, newBulkRouter(store.NewMemoryStore()), MIMECSV, "", body.String())
+	if len(report.Errors) != maxReportedErrors || report.ErrorsTruncated {
+		t.Errorf("at the cap: reported %d errors, truncated %v", len(report.Errors), report.ErrorsTruncated)
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// etag is the strong entity tag of a person's current version written as
+// mimeType, such as "3-json". A strong tag promises identical bytes, so each
+// format of one version gets its own.
+func etag(p Person, mimeType string) string {
+	return `"` + strconv.FormatInt(p.Version, 10) + "-" + formatName(mimeType) + `"`
+}
+
+// currentTag reports whether tag is the strong entity tag of p in any of
+// the formats it is offered in. A write may be conditional on the version
+// the client read in another format than the one it sends.
+func currentTag(p Person, tag string) bool {
+	for _, f := range offers(p) {
+		if tag == etag(p, f.mimes[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+func setValidators(ctx *gin.Context, p Person, mimeType string) {
+	ctx.Header("ETag", etag(p, mimeType))
+	ctx.Header("Last-Modified", p.UpdatedAt.UTC().Format(http.TimeFormat))
+}
+
+// notModified reports whether the client's cached copy of p, written as
+// mimeType, is current, using If-None-Match or, only in its absence,
+// If-Modified-Since.
+func notModified(ctx *gin.Context, p Person, mimeType string) bool {
+	if header := ctx.GetHeader("If-None-Match"); header != "" {
+		// If-None-Match uses the weak comparison.
+		for _, tag := range entityTags(header) {
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == etag(p, mimeType) {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(ctx.GetHeader("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !p.UpdatedAt.Truncate(time.Second).After(since)
+}
+
+// ifMatch evaluates If-Match against current and returns the version a
+// write must be conditional on: zero without the header, matched otherwise.
+// It answers 412 and returns false when no tag matches.
+func ifMatch(ctx *gin.Context, current Person) (int64, bool) {
+	header := ctx.GetHeader("If-Match")
+	if header == "" {
+		return 0, true
+	}
+	for _, tag := range entityTags(header) {
+		// Strong comparison: weak tags never match.
+		if tag == "*" || currentTag(current, tag) {
+			return current.Version, true
+		}
+	}
+	preconditionFailed(ctx, current)
+	return 0, false
+}
+
+// preconditionFailed answers 412 with the current ETag in the format the
+// client would read the person in.
+func preconditionFailed(ctx *gin.Context, current Person) {
+	mimeType, ok := negotiate(ctx, binding.MIMEJSON, offers(current))
+	if !ok {
+		mimeType = binding.MIMEJSON
+	}
+	ctx.Header("ETag", etag(current, mimeType))
+	problem.Write(ctx, problem.New(http.StatusPreconditionFailed, "the person has been modified; fetch it again and retry with its current ETag"))
+}
+
+func entityTags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+	"github.com/faishalshidqi/gin-introductory-proj/src/store"
+	"github.com/gin-gonic/gin"
+)
+
+// newPersonsRouter serves the single-person routes over a store holding
+// one person at version 1.
+func newPersonsRouter(t *testing.T) (*gin.Engine, Person) {
+	t.Helper()
+	s := store.NewMemoryStore()
+	p, err := s.Create(context.Background(), models.Person{FirstName: "Ada", LastName: "Lovelace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewPersonsHandler(s, Paging{})
+	router := gin.New()
+	router.GET("/persons/:id", h.GetPersonHandler)
+	router.HEAD("/persons/:id", h.GetPersonHandler)
+	router.PUT("/persons/:id", h.UpdatePersonHandler)
+	router.PATCH("/persons/:id", h.PatchPersonHandler)
+	router.DELETE("/persons/:id", h.DeletePersonHandler)
+	return router, p
+}
+
+func TestConditionalRequests(t *testing.T) {
+	lastModified := func(p Person) string { return p.UpdatedAt.UTC().Format(http.TimeFormat) }
+	tests := []struct {
+		name     string
+		method   string
+		header   func(p Person) http.Header
+		body     string
+		want     int
+		wantETag string
+	}{
+		{"get json", "GET", nil, "", 200, `"1-json"`},
+		{"get xml", "GET", func(Person) http.Header { return http.Header{"Accept": {"application/xml"}} }, "", 200, `"1-xml"`},
+		{"get protobuf", "GET", func(Person) http.Header { return http.Header{"Accept": {"application/x-protobuf"}} }, "", 200, `"1-protobuf"`},
+		{"if-none-match current", "GET", func(Person) http.Header { return http.Header{"If-None-Match": {`"1-json"`}} }, "", 304, `"1-json"`},
+		{"if-none-match weak", "GET", func(Person) http.Header { return http.Header{"If-None-Match": {`"0-json", W/"1-json"`}} }, "", 304, `"1-json"`},
+		{"if-none-match star", "GET", func(Person) http.Header { return http.Header{"If-None-Match": {"*"}} }, "", 304, `"1-json"`},
+		{"if-none-match other format", "GET", func(Person) http.Header {
+			return http.Header{"If-None-Match": {`"1-json"`}, "Accept": {"application/xml"}}
+		}, "", 200, `"1-xml"`},
+		{"if-none-match stale", "GET", func(Person) http.Header { return http.Header{"If-None-Match": {`"0-json"`}} }, "", 200, `"1-json"`},
+		{"head if-none-match", "HEAD", func(Person) http.Header { return http.Header{"If-None-Match": {`"1-json"`}} }, "", 304, `"1-json"`},
+		{"if-modified-since current", "GET", func(p Person) http.Header {
+			return http.Header{"If-Modified-Since": {lastModified(p)}}
+		}, "", 304, `"1-json"`},
+		{"if-modified-since earlier", "GET", func(p Person) http.Header {
+			return http.Header{"If-Modified-Since": {p.UpdatedAt.Add(-time.Hour).UTC().Format(http.TimeFormat)}}
+		}, "", 200, `"1-json"`},
+		{"if-none-match wins over if-modified-since", "GET", func(p Person) http.Header {
+			return http.Header{"If-None-Match": {`"0-json"`}, "If-Modified-Since": {lastModified(p)}}
+		}, "", 200, `"1-json"`},
+
+		{"put if-match", "PUT", func(Person) http.Header { return http.Header{"If-Match": {`"1-json"`}} }, `{"firstName":"Ada","lastName":"Byron"}`, 200, `"2-json"`},
+		{"put if-match read as xml", "PUT", func(Person) http.Header { return http.Header{"If-Match": {`"1-xml"`}} }, `{"firstName":"Ada","lastName":"Byron"}`, 200, `"2-json"`},
+		{"put if-match star", "PUT", func(Person) http.Header { return http.Header{"If-Match": {"*"}} }, `{"firstName":"Ada","lastName":"Byron"}`, 200, `"2-json"`},
+		{"put if-match stale", "PUT", func(Person) http.Header { return http.Header{"If-Match": {`"0-json"`}} }, `{"firstName":"Ada","lastName":"Byron"}`, 412, `"1-json"`},
+		{"put if-match weak", "PUT", func(Person) http.Header { return http.Header{"If-Match": {`W/"1-json"`}} }, `{"firstName":"Ada","lastName":"Byron"}`, 412, `"1-json"`},
+		{"put if-match bare version", "PUT", func(Person) http.Header { return http.Header{"If-Match": {`"1"`}} }, `{"firstName":"Ada","lastName":"Byron"}`, 412, `"1-json"`},
+		{"put if-match unknown format", "PUT", func(Person) http.Header { return http.Header{"If-Match": {`"1-csv"`}} }, `{"firstName":"Ada","lastName":"Byron"}`, 412, `"1-json"`},
+		{"put stale, answered in xml", "PUT", func(Person) http.Header {
+			return http.Header{"If-Match": {`"0-json"`}, "Accept": {"application/xml"}}
+		}, `{"firstName":"Ada","lastName":"Byron"}`, 412, `"1-xml"`},
+		{"patch if-match", "PATCH", func(Person) http.Header {
+			return http.Header{"If-Match": {`"1-json"`}, "Content-Type": {"application/merge-patch+json"}}
+		}, `{"lastName":"Byron"}`, 200, `"2-json"`},
+		{"patch if-match stale", "PATCH", func(Person) http.Header {
+			return http.Header{"If-Match": {`"2-json"`}, "Content-Type": {"application/merge-patch+json"}}
+		}, `{"lastName":"Byron"}`, 412, `"1-json"`},
+		{"delete if-match", "DELETE", func(Person) http.Header { return http.Header{"If-Match": {`"1-yaml"`}} }, "", 204, ""},
+		{"delete if-match stale", "DELETE", func(Person) http.Header { return http.Header{"If-Match": {`"2-yaml"`}} }, "", 412, `"1-json"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, p := newPersonsRouter(t)
+			req := httptest.NewRequest(tt.method, "/persons/"+p.ID, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if tt.header != nil {
+				for k, v := range tt.header(p) {
+					req.Header[k] = v
+				}
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if got := w.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("ETag = %s, want %s", got, tt.wantETag)
+			}
+			if w.Code == http.StatusNotModified && w.Body.Len() > 0 {
+				t.Errorf("304 has a body: %s", w.Body)
+			}
+		})
+	}
+}
@@ -1,29 +1,38 @@
 package handlers
 
 import (
-	"encoding/xml"
-	"fmt"
-
+	"github.com/faishalshidqi/gin-introductory-proj/src/i18n"
+	"github.com/faishalshidqi/gin-introductory-proj/src/logging"
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+	"github.com/faishalshidqi/gin-introductory-proj/src/validation"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
+type Greeting = models.Greeting
+
 func IndexHandler(ctx *gin.Context) {
-	name := ctx.Params.ByName("name")
+	params := greetingParams{Name: validation.NormalizeName(ctx.Params.ByName("name"))}
+	if !valid(ctx, &params) {
+		return
+	}
 
-	ctx.JSON(200, gin.H{
-		"message": fmt.Sprintf("hello %v", name),
-	})
-}
+	message, lang := i18n.Default().Format(requestLanguage(ctx), "greeting", map[string]string{"name": params.Name})
+	logging.FromContext(ctx).Debug("greeting", "name", params.Name, "lang", lang.String())
 
-type Person struct {
-	XMLName   xml.Name `xml:"person"`
-	FirstName string   `xml:"firstName,attr"`
-	LastName  string   `xml:"lastName,attr"`
+	ctx.Header("Content-Language", lang.String())
+	ctx.Writer.Header().Add("Vary", "Accept-Language")
+	respond(ctx, 200, Greeting{
+		Message: message,
+	}, binding.MIMEJSON)
 }
 
+type Person = models.Person
+
 func PersonHandler(ctx *gin.Context) {
-	ctx.XML(200, Person{
+	logging.FromContext(ctx).Debug("serving sample person")
+	respond(ctx, 200, models.SamplePerson{
 		FirstName: "Tester",
 		LastName:  "Testing",
-	})
+	}, binding.MIMEXML)
 }
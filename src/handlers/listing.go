@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/pagination"
+	"github.com/faishalshidqi/gin-introductory-proj/src/problem"
+	"github.com/faishalshidqi/gin-introductory-proj/src/validation"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/cases"
+)
+
+// Paging configures collection listings.
+type Paging struct {
+	Cursors      *pagination.Signer
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// sortTime renders timestamps so that they order correctly as strings.
+const sortTime = "2006-01-02T15:04:05.000000000Z"
+
+// personFields are the fields a listing can be sorted on. Names compare
+// case-insensitively first so "de Vries" sorts next to "De Vries".
+var personFields = map[string]struct {
+	value func(Person) string
+	name  bool
+}{
+	"id":        {value: func(p Person) string { return p.ID }},
+	"firstName": {value: func(p Person) string { return p.FirstName }, name: true},
+	"lastName":  {value: func(p Person) string { return p.LastName }, name: true},
+	"createdAt": {value: func(p Person) string { return p.CreatedAt.UTC().Format(sortTime) }},
+	"updatedAt": {value: func(p Person) string { return p.UpdatedAt.UTC().Format(sortTime) }},
+}
+
+// filterOps are the operators accepted as lastName[op]=value; a bare
+// lastName=value means eq.
+var filterOps = []string{"eq", "ieq", "prefix", "iprefix"}
+
+// fold applies Unicode case folding. A Caser is stateful, so each call gets
+// its own.
+func fold(s string) string {
+	return cases.Fold().String(s)
+}
+
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+type personFilter struct {
+	field string
+	op    string
+	value string
+}
+
+func (f personFilter) match(p Person) bool {
+	v := personFields[f.field].value(p)
+	switch f.op {
+	case "ieq":
+		return fold(v) == f.value
+	case "prefix":
+		return strings.HasPrefix(v, f.value)
+	case "iprefix":
+		return strings.HasPrefix(fold(v), f.value)
+	default:
+		return v == f.value
+	}
+}
+
+// personQuery is a parsed GET /persons request.
+type personQuery struct {
+	sort    []sortKey
+	filters []personFilter
+	limit   int
+	cursor  *pagination.Cursor
+	// fingerprint identifies sort and filters for cursor binding.
+	fingerprint string
+}
+
+func (h *PersonsHandler) parsePersonQuery(ctx *gin.Context) (*personQuery, []problem.InvalidParam) {
+	var invalid []problem.InvalidParam
+	params := ctx.Request.URL.Query()
+	q := &personQuery{limit: h.paging.DefaultLimit}
+
+	if raw := params.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > h.paging.MaxLimit {
+			invalid = append(invalid, problem.InvalidParam{
+				Name:   "limit",
+				Reason: fmt.Sprintf("must be an integer between 1 and %d", h.paging.MaxLimit),
+			})
+		}
+		q.limit = n
+	}
+
+	spec := params.Get("sort")
+	if spec == "" {
+		spec = "createdAt"
+	}
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(spec, ",") {
+		key := sortKey{field: strings.TrimSpace(item)}
+		if rest, ok := strings.CutPrefix(key.field, "-"); ok {
+			key.field, key.desc = rest, true
+		}
+		if _, ok := personFields[key.field]; !ok || seen[key.field] {
+			invalid = append(invalid, problem.InvalidParam{
+				Name:   "sort",
+				Reason: fmt.Sprintf("unknown or repeated field %q; use firstName, lastName, createdAt, updatedAt or id, prefixed with - for descending", key.field),
+			})
+			continue
+		}
+		seen[key.field] = true
+		q.sort = append(q.sort, key)
+	}
+	// id breaks ties so the order, and therefore every cursor, is total.
+	if !seen["id"] {
+		q.sort = append(q.sort, sortKey{field: "id"})
+	}
+
+	for _, field := range []string{"firstName", "lastName"} {
+		for name, values := range params {
+			op, ok := filterOp(name, field)
+			if !ok {
+				continue
+			}
+			if !slices.Contains(filterOps, op) {
+				invalid = append(invalid, problem.InvalidParam{
+					Name:   name,
+					Reason: "operator must be one of " + strings.Join(filterOps, ", "),
+				})
+				continue
+			}
+			for _, v := range values {
+				v = validation.NormalizeName(v)
+				if op == "ieq" || op == "iprefix" {
+					v = fold(v)
+				}
+				q.filters = append(q.filters, personFilter{field: field, op: op, value: v})
+			}
+		}
+	}
+	sort.Slice(q.filters, func(i, j int) bool {
+		a, b := q.filters[i], q.filters[j]
+		return a.field+"\x00"+a.op+"\x00"+a.value < b.field+"\x00"+b.op+"\x00"+b.value
+	})
+
+	var canonical strings.Builder
+	for _, k := range q.sort {
+		fmt.Fprintf(&canonical, "sort=%s:%t;", k.field, k.desc)
+	}
+	for _, f := range q.filters {
+		fmt.Fprintf(&canonical, "filter=%s:%s:%q;", f.field, f.op, f.value)
+	}
+	q.fingerprint = pagination.Fingerprint(canonical.String())
+
+	if raw := params.Get("cursor"); raw != "" {
+		c, err := h.paging.Cursors.Decode(raw)
+		switch {
+		case err != nil:
+			invalid = append(invalid, problem.InvalidParam{Name: "cursor", Reason: err.Error()})
+		case c.Query != q.fingerprint || len(c.Values) != len(q.sort):
+			invalid = append(invalid, problem.InvalidParam{Name: "cursor", Reason: "cursor was issued for a different sort or filter"})
+		default:
+			q.cursor = &c
+		}
+	}
+	return q, invalid
+}
+
+// filterOp matches "field" and "field[op]" parameter names.
+func filterOp(name, field string) (string, bool) {
+	if name == field {
+		return "eq", true
+	}
+	rest, ok := strings.CutPrefix(name, field+"[")
+	if !ok || !strings.HasSuffix(rest, "]") {
+		return "", false
+	}
+	return strings.TrimSuffix(rest, "]"), true
+}
+
+func (q *personQuery) values(p Person) []string {
+	out := make([]string, len(q.sort))
+	for i, k := range q.sort {
+		out[i] = personFields[k.field].value(p)
+	}
+	return out
+}
+
+// compare orders two records' sort-key values under q.sort.
+func (q *personQuery) compare(a, b []string) int {
+	for i, k := range q.sort {
+		c := 0
+		if personFields[k.field].name {
+			c = cmp.Compare(fold(a[i]), fold(b[i]))
+		}
+		if c == 0 {
+			c = cmp.Compare(a[i], b[i])
+		}
+		if k.desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// page filters and sorts persons and cuts out the page the cursor points
+// at, reporting whether records exist on either side of it.
+func (q *personQuery) page(persons []Person) (page []Person, hasPrev, hasNext bool) {
+	type row struct {
+		person Person
+		values []string
+	}
+	rows := make([]row, 0, len(persons))
+	for _, p := range persons {
+		if q.matches(p) {
+			rows = append(rows, row{p, q.values(p)})
+		}
+	}
+	slices.SortFunc(rows, func(a, b row) int { return q.compare(a.values, b.values) })
+
+	start, end := 0, len(rows)
+	if q.cursor != nil {
+		boundary, _ := slices.BinarySearchFunc(rows, q.cursor.Values, func(r row, v []string) int {
+			return q.compare(r.values, v)
+		})
+		if q.cursor.Before {
+			end = boundary
+			start = max(0, end-q.limit)
+		} else {
+			// Skip the boundary record itself if it still exists.
+			if boundary < len(rows) && q.compare(rows[boundary].values, q.cursor.Values) == 0 {
+				boundary++
+			}
+			start = boundary
+		}
+	}
+	end = min(end, start+q.limit)
+
+	page = make([]Person, 0, end-start)
+	for _, r := range rows[start:end] {
+		page = append(page, r.person)
+	}
+	return page, start > 0, end < len(rows)
+}
+
+func (q *personQuery) matches(p Person) bool {
+	for _, f := range q.filters {
+		if !f.match(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// pageLinks sets a Link header with next and prev relations that repeat
+// the request's query with a fresh cursor.
+func (h *PersonsHandler) pageLinks(ctx *gin.Context, q *personQuery, page []Person, hasPrev, hasNext bool) {
+	link := func(p Person, before bool, rel string) string {
+		params := ctx.Request.URL.Query()
+		params.Set("cursor", h.paging.Cursors.Encode(pagination.Cursor{
+			Values: q.values(p),
+			Before: before,
+			Query:  q.fingerprint,
+		}))
+		u := url.URL{Path: ctx.Request.URL.Path, RawQuery: params.Encode()}
+		return pagination.Link(&u, rel)
+	}
+	var links []string
+	if hasNext && len(page) > 0 {
+		links = append(links, link(page[len(page)-1], false, "next"))
+	}
+	if hasPrev && len(page) > 0 {
+		links = append(links, link(page[0], true, "prev"))
+	}
+	if len(links) > 0 {
+		ctx.Header("Link", strings.Join(links, ", "))
+	}
+}
+
+func invalidQuery(ctx *gin.Context, params []problem.InvalidParam) {
+	p := problem.New(http.StatusBadRequest, "invalid query parameters")
+	p.Type = problem.TypeValidation
+	p.InvalidParams = params
+	problem.Write(ctx, p)
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+	"github.com/faishalshidqi/gin-introductory-proj/src/models/pb"
+	"github.com/faishalshidqi/gin-introductory-proj/src/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+	"google.golang.org/protobuf/proto"
+)
+
+// protoConvertible is implemented by response types that have a protobuf
+// counterpart; anything else cannot be rendered as application/x-protobuf.
+type protoConvertible interface {
+	Proto() proto.Message
+}
+
+type format struct {
+	name  string
+	mimes []string
+}
+
+var formats = []format{
+	{"json", []string{binding.MIMEJSON}},
+	{"xml", []string{binding.MIMEXML, binding.MIMEXML2}},
+	{"yaml", []string{binding.MIMEYAML, binding.MIMEYAML2}},
+	{"toml", []string{binding.MIMETOML}},
+	{"msgpack", []string{binding.MIMEMSGPACK, binding.MIMEMSGPACK2}},
+	{"protobuf", []string{binding.MIMEPROTOBUF}},
+}
+
+// offers returns the formats obj can be written in: every one of them,
+// except protobuf for types without a protobuf counterpart.
+func offers(obj any) []format {
+	if _, ok := obj.(protoConvertible); ok {
+		return formats
+	}
+	return slices.DeleteFunc(slices.Clone(formats), func(f format) bool {
+		return f.name == "protobuf"
+	})
+}
+
+// formatName names the format mimeType belongs to, json for unknown types
+// as write falls back to JSON.
+func formatName(mimeType string) string {
+	for _, f := range formats {
+		if slices.Contains(f.mimes, mimeType) {
+			return f.name
+		}
+	}
+	return "json"
+}
+
+func mimesOf(offered []format) []string {
+	var mimes []string
+	for _, f := range offered {
+		mimes = append(mimes, f.mimes...)
+	}
+	return mimes
+}
+
+type acceptRange struct {
+	typ, subtype string
+	q            float64
+}
+
+func parseAcceptHeader(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		ranges = append(ranges, acceptRange{typ: typ, subtype: subtype, q: q})
+	}
+	return ranges
+}
+
+// quality returns the q-value the most specific matching range assigns to
+// mimeType, or -1 when no range matches.
+func quality(ranges []acceptRange, mimeType string) float64 {
+	typ, subtype, _ := strings.Cut(mimeType, "/")
+	best, specificity := -1.0, -1
+	for _, r := range ranges {
+		s := -1
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*" && r.subtype == "*":
+			s = 0
+		}
+		if s > specificity {
+			best, specificity = r.q, s
+		}
+	}
+	return best
+}
+
+// negotiate picks the response MIME type among offered from the ?format=
+// override or the Accept header. preferred wins ties, so wildcard clients
+// keep getting each handler's historical format.
+func negotiate(ctx *gin.Context, preferred string, offered []format) (string, bool) {
+	if name := ctx.Query("format"); name != "" {
+		for _, f := range offered {
+			if f.name == name {
+				return f.mimes[0], true
+			}
+		}
+		return "", false
+	}
+
+	header := ctx.GetHeader("Accept")
+	if strings.TrimSpace(header) == "" {
+		return preferred, true
+	}
+	ranges := parseAcceptHeader(header)
+	chosen, bestQ := "", 0.0
+	for _, offer := range append([]string{preferred}, mimesOf(offered)...) {
+		if q := quality(ranges, offer); q > bestQ {
+			chosen, bestQ = offer, q
+		}
+	}
+	return chosen, chosen != ""
+}
+
+// respond writes obj in the best format it offers, or a 406 listing those
+// formats when none of them is acceptable. A client preferring protobuf
+// thus gets its next choice for a type protobuf cannot carry.
+func respond(ctx *gin.Context, code int, obj any, preferred string) {
+	if mimeType, ok := negotiateResponse(ctx, obj, preferred); ok {
+		write(ctx, code, obj, mimeType)
+	}
+}
+
+// respondPerson is respond for a single person, with the validators of the
+// representation chosen.
+func respondPerson(ctx *gin.Context, code int, p Person) {
+	if mimeType, ok := negotiateResponse(ctx, p, binding.MIMEJSON); ok {
+		setValidators(ctx, p, mimeType)
+		write(ctx, code, p, mimeType)
+	}
+}
+
+// negotiateResponse picks the format to write obj in, answering 406 and
+// returning false when there is none.
+func negotiateResponse(ctx *gin.Context, obj any, preferred string) (string, bool) {
+	ctx.Writer.Header().Add("Vary", "Accept")
+	offered := offers(obj)
+	mimeType, ok := negotiate(ctx, preferred, offered)
+	if !ok {
+		problem.Write(ctx, problem.New(http.StatusNotAcceptable, "none of the accepted formats are offered").
+			With("supported", mimesOf(offered)))
+	}
+	return mimeType, ok
+}
+
+func write(ctx *gin.Context, code int, obj any, mimeType string) {
+	switch mimeType {
+	case binding.MIMEXML, binding.MIMEXML2:
+		ctx.XML(code, obj)
+	case binding.MIMEYAML, binding.MIMEYAML2:
+		ctx.YAML(code, obj)
+	case binding.MIMETOML:
+		ctx.TOML(code, obj)
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		ctx.Render(code, render.MsgPack{Data: obj})
+	case binding.MIMEPROTOBUF:
+		ctx.ProtoBuf(code, obj.(protoConvertible).Proto())
+	default:
+		ctx.JSON(code, obj)
+	}
+}
+
+// renderError reports err as a problem+json body regardless of the
+// negotiated format, so clients have one error shape to parse.
+func renderError(ctx *gin.Context, code int, err error) {
+	problem.Write(ctx, problem.New(code, err.Error()))
+}
+
+// bindPerson decodes the request body into person, accepting every format
+// respond can produce.
+func bindPerson(ctx *gin.Context, person *Person) error {
+	if ctx.ContentType() != binding.MIMEPROTOBUF {
+		return ctx.ShouldBind(person)
+	}
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return err
+	}
+	var msg pb.Person
+	if err := proto.Unmarshal(body, &msg); err != nil {
+		return err
+	}
+	*person = models.PersonFromProto(&msg)
+	return nil
+}
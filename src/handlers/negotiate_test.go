@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// plain has no protobuf counterpart.
+type plain struct {
+	Name string `json:"name" xml:"name" yaml:"name" toml:"name"`
+}
+
+func TestNegotiate(t *testing.T) {
+	router := gin.New()
+	router.GET("/person", func(c *gin.Context) {
+		respond(c, http.StatusOK, Person{ID: "p1", FirstName: "Ada", LastName: "Lovelace"}, binding.MIMEJSON)
+	})
+	router.GET("/plain", func(c *gin.Context) {
+		respond(c, http.StatusOK, plain{Name: "x"}, binding.MIMEJSON)
+	})
+	// Handlers that historically answered in XML keep doing so for
+	// clients that do not care.
+	router.GET("/plain-xml", func(c *gin.Context) {
+		respond(c, http.StatusOK, plain{Name: "x"}, binding.MIMEXML)
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+		query  string
+		// want is a media type of the format expected, or empty for a 406.
+		want string
+	}{
+		{name: "no Accept", path: "/person", want: binding.MIMEJSON},
+		{name: "no Accept keeps the preferred format", path: "/plain-xml", want: binding.MIMEXML},
+		{name: "anything", path: "/plain-xml", accept: "*/*", want: binding.MIMEXML},
+		{name: "unparseable ranges are ignored", path: "/person", accept: "garbage, application/xml", want: binding.MIMEXML},
+
+		// Every format by each of its media types and by ?format=.
+		{name: "json", path: "/person", accept: binding.MIMEJSON, want: binding.MIMEJSON},
+		{name: "xml", path: "/person", accept: binding.MIMEXML, want: binding.MIMEXML},
+		{name: "text/xml", path: "/person", accept: binding.MIMEXML2, want: binding.MIMEXML2},
+		{name: "yaml", path: "/person", accept: binding.MIMEYAML, want: binding.MIMEYAML},
+		{name: "application/yaml", path: "/person", accept: binding.MIMEYAML2, want: binding.MIMEYAML2},
+		{name: "toml", path: "/person", accept: binding.MIMETOML, want: binding.MIMETOML},
+		{name: "msgpack", path: "/person", accept: binding.MIMEMSGPACK, want: binding.MIMEMSGPACK},
+		{name: "application/msgpack", path: "/person", accept: binding.MIMEMSGPACK2, want: binding.MIMEMSGPACK},
+		{name: "protobuf", path: "/person", accept: binding.MIMEPROTOBUF, want: binding.MIMEPROTOBUF},
+		{name: "format=json", path: "/person", query: "json", accept: binding.MIMEXML, want: binding.MIMEJSON},
+		{name: "format=xml", path: "/person", query: "xml", want: binding.MIMEXML},
+		{name: "format=yaml", path: "/person", query: "yaml", want: binding.MIMEYAML},
+		{name: "format=toml", path: "/person", query: "toml", want: binding.MIMETOML},
+		{name: "format=msgpack", path: "/person", query: "msgpack", want: binding.MIMEMSGPACK},
+		{name: "format=protobuf", path: "/person", query: "protobuf", want: binding.MIMEPROTOBUF},
+		{name: "unknown format", path: "/person", query: "csv"},
+
+		// q-values and specificity.
+		{name: "highest q wins", path: "/person", accept: "application/xml;q=0.5, application/x-yaml;q=0.9", want: binding.MIMEYAML},
+		{name: "ties go to the preferred format", path: "/person", accept: "application/xml, application/json", want: binding.MIMEJSON},
+		{name: "specific range beats wildcard", path: "/person", accept: "application/json;q=0.1, */*;q=0.5", want: binding.MIMEXML},
+		{name: "q=0 refuses", path: "/person", accept: "application/json;q=0, application/*", want: binding.MIMEXML},
+		{name: "subtype wildcard", path: "/person", accept: "text/*", want: binding.MIMEXML2},
+		{name: "everything refused", path: "/person", accept: "*/*;q=0"},
+		{name: "nothing offered matches", path: "/person", accept: "text/csv"},
+
+		// Types without a protobuf counterpart are not offered as protobuf.
+		{name: "protobuf without a counterpart", path: "/plain", accept: binding.MIMEPROTOBUF},
+		{name: "format=protobuf without a counterpart", path: "/plain", query: "protobuf"},
+		{name: "next choice after protobuf", path: "/plain", accept: "application/x-protobuf, application/x-yaml;q=0.5", want: binding.MIMEYAML},
+		{name: "protobuf first when it can", path: "/person", accept: "application/x-protobuf, application/x-yaml;q=0.5", want: binding.MIMEPROTOBUF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.path
+			if tt.query != "" {
+				target += "?format=" + tt.query
+			}
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if !slices.Contains(w.Header().Values("Vary"), "Accept") {
+				t.Errorf("Vary = %v, want Accept", w.Header().Values("Vary"))
+			}
+			if tt.want == "" {
+				if w.Code != http.StatusNotAcceptable {
+					t.Fatalf("status = %d, want 406", w.Code)
+				}
+				var p struct {
+					Supported []string `json:"supported"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+					t.Fatal(err)
+				}
+				wantProto := tt.path == "/person"
+				if len(p.Supported) == 0 || slices.Contains(p.Supported, binding.MIMEPROTOBUF) != wantProto {
+					t.Errorf("supported = %v, want protobuf listed: %v", p.Supported, wantProto)
+				}
+				return
+			}
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+			}
+			// gin's renderers write their own media type for a format, so
+			// text/xml is answered as application/xml.
+			got, _, _ := strings.Cut(w.Header().Get("Content-Type"), ";")
+			if i := slices.IndexFunc(formats, func(f format) bool { return f.name == formatName(tt.want) }); !slices.Contains(formats[i].mimes, got) {
+				t.Errorf("Content-Type = %q, want %s", got, formats[i].name)
+			}
+			if w.Body.Len() == 0 {
+				t.Error("empty body")
+			}
+		})
+	}
+}
+
+func TestParseAcceptHeader(t *testing.T) {
+	ranges := parseAcceptHeader(`application/json;q=0.8, text/*, bad/, */*;q=x, application/xml;charset="utf-8";q=0.1`)
+	want := []acceptRange{
+		{"application", "json", 0.8},
+		{"text", "*", 1},
+		{"*", "*", 1},
+		{"application", "xml", 0.1},
+	}
+	if !slices.Equal(ranges, want) {
+		t.Errorf("ranges = %v, want %v", ranges, want)
+	}
+	tests := []struct {
+		mime string
+		want float64
+	}{
+		{"application/json", 0.8},
+		{"application/xml", 0.1},
+		{"text/csv", 1},
+		{"image/png", 1},
+	}
+	for _, tt := range tests {
+		if got := quality(ranges, tt.mime); got != tt.want {
+			t.Errorf("quality(%s) = %v, want %v", tt.mime, got, tt.want)
+		}
+	}
+	if got := quality(parseAcceptHeader("text/html"), "application/json"); got != -1 {
+		t.Errorf("quality of an unmatched type = %v, want -1", got)
+	}
+}
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"reflect"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/apikeys"
+	"github.com/faishalshidqi/gin-introductory-proj/src/jsonpatch"
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+	"github.com/faishalshidqi/gin-introductory-proj/src/openapi"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// The operation tables below document the routes of each handler, keyed
+// by method and gin path as registered in the server, or by the documented
+// path for operations with a Route. openapi.Build fails when a registered
+// route is missing from them, or they list one that is not registered.
+
+// IndexOperations documents IndexHandler.
+func IndexOperations() map[string]*openapi.Operation {
+	return map[string]*openapi.Operation{
+		"GET /:name": {
+			OperationID: "greet",
+			Summary:     "Greet someone by name",
+			Description: "The greeting is localized from the Accept-Language header.",
+			Tags:        []string{"greetings"},
+			Parameters: []*openapi.Parameter{
+				{Name: "name", In: "path", Required: true, Schema: openapi.Of[greetingParams]().Property("name")},
+				formatParameter(),
+			},
+			Responses: map[string]*openapi.Response{
+				"200": {
+					Description: "The greeting",
+					Headers:     map[string]*openapi.Header{"Content-Language": {Schema: openapi.String()}},
+					Content:     negotiated[Greeting](),
+				},
+				"400": openapi.ProblemResponse("The name is not valid"),
+				"404": openapi.ProblemResponse("The name is an unknown custom method, such as persons:foo"),
+				"406": openapi.ProblemResponse("No acceptable format is offered"),
+			},
+			Public: true,
+		},
+	}
+}
+
+// PersonOperations documents PersonHandler.
+func PersonOperations() map[string]*openapi.Operation {
+	return map[string]*openapi.Operation{
+		"GET /person": {
+			OperationID: "samplePerson",
+			Summary:     "Return a sample person",
+			Tags:        []string{"persons"},
+			Parameters:  []*openapi.Parameter{formatParameter()},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "The sample person", Content: negotiated[models.SamplePerson]()},
+				"406": openapi.ProblemResponse("No acceptable format is offered"),
+			},
+		},
+	}
+}
+
+// PersonsOperations documents PersonsHandler and SearchHandler.
+func PersonsOperations() map[string]*openapi.Operation {
+	id := &openapi.Parameter{Name: "id", In: "path", Required: true, Schema: openapi.String()}
+	ifMatch := &openapi.Parameter{
+		Name: "If-Match", In: "header", Schema: openapi.String(),
+		Description: "Only apply the change if the person still has this ETag, in any format.",
+	}
+	validators := map[string]*openapi.Header{
+		"ETag":          {Description: `The person's version and the response's format, such as "3-json"`, Schema: openapi.String()},
+		"Last-Modified": {Schema: openapi.String()},
+	}
+	writeBody := &openapi.RequestBody{Required: true, Content: negotiated[Person]()}
+
+	return map[string]*openapi.Operation{
+		"GET /persons": {
+			OperationID: "listPersons",
+			Summary:     "List persons one page at a time",
+			Description: "Further pages are linked from the Link header with rel next and prev.",
+			Tags:        []string{"persons"},
+			Parameters: []*openapi.Parameter{
+				{Name: "limit", In: "query", Schema: openapi.Integer(), Description: "Page size"},
+				{Name: "cursor", In: "query", Schema: openapi.String(), Description: "Opaque cursor from a Link header"},
+				{Name: "sort", In: "query", Schema: openapi.String(),
+					Description: "Comma separated firstName, lastName, createdAt, updatedAt or id; prefix - for descending"},
+				{Name: "firstName", In: "query", Schema: openapi.String(),
+					Description: "Filter; also firstName[eq], [ieq], [prefix] and [iprefix]"},
+				{Name: "lastName", In: "query", Schema: openapi.String(),
+					Description: "Filter; also lastName[eq], [ieq], [prefix] and [iprefix]"},
+				formatParameter(),
+			},
+			Responses: map[string]*openapi.Response{
+				"200": {
+					Description: "A page of persons",
+					Headers:     map[string]*openapi.Header{"Link": {Schema: openapi.String()}},
+					Content:     negotiated[models.PersonList](),
+				},
+				"400": openapi.ProblemResponse("A query parameter is not valid"),
+				"406": openapi.ProblemResponse("No acceptable format is offered"),
+			},
+		},
+		"GET /persons/search": {
+			OperationID: "searchPersons",
+			Summary:     "Search persons by name, best matches first",
+			Description: "Matching ignores case and diacritics and tolerates small typos.",
+			Tags:        []string{"persons"},
+			Parameters: []*openapi.Parameter{
+				{Name: "q", In: "query", Required: true, Schema: openapi.Of[searchParams]().Property("q")},
+				{Name: "limit", In: "query", Schema: openapi.Integer()},
+				formatParameter(),
+			},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "The matching persons", Content: negotiated[models.PersonList]()},
+				"400": openapi.ProblemResponse("A query parameter is not valid"),
+				"406": openapi.ProblemResponse("No acceptable format is offered"),
+			},
+		},
+		"POST /persons": {
+			OperationID: "createPerson",
+			Summary:     "Create a person",
+			Tags:        []string{"persons"},
+			Parameters:  []*openapi.Parameter{formatParameter()},
+			RequestBody: writeBody,
+			Responses: map[string]*openapi.Response{
+				"201": {
+					Description: "The created person",
+					Headers: map[string]*openapi.Header{
+						"Location":      {Schema: openapi.String()},
+						"ETag":          validators["ETag"],
+						"Last-Modified": validators["Last-Modified"],
+					},
+					Content: negotiated[Person](),
+				},
+				"400": openapi.ProblemResponse("The person is not valid"),
+				"406": openapi.ProblemResponse("No acceptable format is offered"),
+			},
+		},
+		"GET /persons/:id": {
+			OperationID: "getPerson",
+			Summary:     "Get a person",
+			Tags:        []string{"persons"},
+			Parameters: []*openapi.Parameter{
+				id,
+				{Name: "If-None-Match", In: "header", Schema: openapi.String()},
+				{Name: "If-Modified-Since", In: "header", Schema: openapi.String()},
+				formatParameter(),
+			},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "The person", Headers: validators, Content: negotiated[Person]()},
+				"304": {Description: "The client's copy is current"},
+				"404": openapi.ProblemResponse("No such person"),
+				"406": openapi.ProblemResponse("No acceptable format is offered"),
+			},
+		},
+		"PUT /persons/:id": {
+			OperationID: "replacePerson",
+			Summary:     "Replace a person's names",
+			Tags:        []string{"persons"},
+			Parameters:  []*openapi.Parameter{id, ifMatch, formatParameter()},
+			RequestBody: writeBody,
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "The updated person", Headers: validators, Content: negotiated[Person]()},
+				"400": openapi.ProblemResponse("The person is not valid"),
+				"406": openapi.ProblemResponse("No acceptable format is offered"),
+				"404": openapi.ProblemResponse("No such person"),
+				"409": openapi.ProblemResponse("The person changed concurrently"),
+				"412": openapi.ProblemResponse("The If-Match precondition failed"),
+			},
+		},
+		"PATCH /persons/:id": {
+			OperationID: "patchPerson",
+			Summary:     "Change some of a person's fields",
+			Description: "Accepts a JSON Patch, a JSON Merge Patch, or a partial person in any supported format.",
+			Tags:        []string{"persons"},
+			Parameters:  []*openapi.Parameter{id, ifMatch, formatParameter()},
+			RequestBody: &openapi.RequestBody{Required: true, Content: patchContent()},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "The updated person", Headers: validators, Content: negotiated[Person]()},
+				"400": openapi.ProblemResponse("The patch is malformed or the result is not valid"),
+				"404": openapi.ProblemResponse("No such person"),
+				"409": openapi.ProblemResponse("A test operation failed or the person changed concurrently"),
+				"412": openapi.ProblemResponse("The If-Match precondition failed"),
+				"406": openapi.ProblemResponse("No acceptable format is offered"),
+				"422": openapi.ProblemResponse("The patch cannot be applied to the person"),
+			},
+		},
+		"DELETE /persons/:id": {
+			OperationID: "deletePerson",
+			Summary:     "Delete a person",
+			Tags:        []string{"persons"},
+			Parameters:  []*openapi.Parameter{id, ifMatch},
+			Responses: map[string]*openapi.Response{
+				"204": {Description: "The person was deleted"},
+				"404": openapi.ProblemResponse("No such person"),
+				"412": openapi.ProblemResponse("The If-Match precondition failed"),
+			},
+		},
+		"GET /persons:export": {
+			Route:       "/:name",
+			OperationID: "exportPersons",
+			Summary:     "Export every person as CSV, NDJSON or XML",
+			Tags:        []string{"persons"},
+			Parameters: []*openapi.Parameter{{
+				Name: "format", In: "query", Description: "Overrides the Accept header",
+				Schema: &openapi.Schema{Type: "string", Enum: []any{"csv", "ndjson", "xml"}},
+			}},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "The persons, streamed", Content: bulkContent(openapi.Of[Person]())},
+				"406": openapi.ProblemResponse("No acceptable format is offered"),
+			},
+		},
+		"POST /persons:import": {
+			Route:       "/:name",
+			OperationID: "importPersons",
+			Summary:     "Create persons from a CSV, NDJSON or XML file",
+			Description: "Rows fail independently; the report lists the failures.",
+			Tags:        []string{"persons"},
+			Parameters: []*openapi.Parameter{
+				{Name: "dryRun", In: "query", Schema: openapi.Boolean(), Description: "Only validate the rows"},
+			},
+			RequestBody:  &openapi.RequestBody{Required: true, Content: bulkContent(openapi.Of[Person]())},
+			StreamedBody: true,
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "The import report", Content: map[string]*openapi.MediaType{
+					binding.MIMEJSON: {Schema: openapi.Of[importReport]()},
+				}},
+				"400": openapi.ProblemResponse("The file cannot be read"),
+				"415": openapi.ProblemResponse("The file format is not supported"),
+			},
+		},
+	}
+}
+
+// APIKeysOperations documents APIKeysHandler.
+func APIKeysOperations() map[string]*openapi.Operation {
+	id := &openapi.Parameter{Name: "id", In: "path", Required: true, Schema: openapi.String()}
+	return map[string]*openapi.Operation{
+		"GET /admin/api-keys": {
+			OperationID: "listAPIKeys",
+			Summary:     "List API keys",
+			Tags:        []string{"api-keys"},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "Every key, without its secret", Content: jsonContent(openapi.Of[struct {
+					APIKeys []apikeys.Key `json:"apiKeys"`
+				}]())},
+			},
+		},
+		"POST /admin/api-keys": {
+			OperationID: "createAPIKey",
+			Summary:     "Mint an API key",
+			Description: "The key itself is only ever returned here and by rotate.",
+			Tags:        []string{"api-keys"},
+			RequestBody: &openapi.RequestBody{Required: true, Content: jsonContent(openapi.Of[apiKeyRequest]())},
+			Responses: map[string]*openapi.Response{
+				"201": {Description: "The minted key", Content: jsonContent(openapi.Of[mintedAPIKey]())},
+				"400": openapi.ProblemResponse("The request is not valid"),
+			},
+		},
+		"GET /admin/api-keys/:id": {
+			OperationID: "getAPIKey",
+			Summary:     "Get an API key",
+			Tags:        []string{"api-keys"},
+			Parameters:  []*openapi.Parameter{id},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "The key, without its secret", Content: jsonContent(openapi.Of[apikeys.Key]())},
+				"404": openapi.ProblemResponse("No such key"),
+			},
+		},
+		"POST /admin/api-keys/:id/rotate": {
+			OperationID: "rotateAPIKey",
+			Summary:     "Replace an API key's secret",
+			Tags:        []string{"api-keys"},
+			Parameters:  []*openapi.Parameter{id},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "The key with its new secret", Content: jsonContent(openapi.Of[mintedAPIKey]())},
+				"404": openapi.ProblemResponse("No such key"),
+				"409": openapi.ProblemResponse("The key is revoked"),
+			},
+		},
+		"DELETE /admin/api-keys/:id": {
+			OperationID: "revokeAPIKey",
+			Summary:     "Revoke an API key",
+			Tags:        []string{"api-keys"},
+			Parameters:  []*openapi.Parameter{id},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "The revoked key", Content: jsonContent(openapi.Of[apikeys.Key]())},
+				"404": openapi.ProblemResponse("No such key"),
+				"409": openapi.ProblemResponse("The key is already revoked"),
+			},
+		},
+	}
+}
+
+// negotiated lists every format respond can render T in. Protobuf is only
+// offered for types with a protobuf counterpart.
+func negotiated[T any]() map[string]*openapi.MediaType {
+	content := make(map[string]*openapi.MediaType)
+	convertible := reflect.TypeFor[T]().Implements(reflect.TypeFor[protoConvertible]())
+	for _, f := range formats {
+		if f.name == "protobuf" {
+			if convertible {
+				content[f.mimes[0]] = &openapi.MediaType{Schema: &openapi.Schema{Type: "string", Format: "binary"}}
+			}
+			continue
+		}
+		content[f.mimes[0]] = &openapi.MediaType{Schema: openapi.Of[T]()}
+	}
+	return content
+}
+
+func jsonContent(schema *openapi.Schema) map[string]*openapi.MediaType {
+	return map[string]*openapi.MediaType{binding.MIMEJSON: {Schema: schema}}
+}
+
+// bulkContent describes the streamed formats of import and export. NDJSON
+// is a sequence of persons, one per line.
+func bulkContent(person *openapi.Schema) map[string]*openapi.MediaType {
+	return map[string]*openapi.MediaType{
+		MIMECSV:         {Schema: &openapi.Schema{Type: "string", Description: "A header row, then one person per row"}},
+		MIMENDJSON:      {Schema: person},
+		binding.MIMEXML: {Schema: openapi.Of[models.PersonList]()},
+	}
+}
+
+func patchContent() map[string]*openapi.MediaType {
+	content := map[string]*openapi.MediaType{
+		jsonpatch.MIMEJSONPatch:  {Schema: openapi.Of[jsonpatch.Patch]()},
+		jsonpatch.MIMEMergePatch: {Schema: &openapi.Schema{Type: "object"}},
+	}
+	for _, mime := range []string{binding.MIMEJSON, binding.MIMEXML, binding.MIMEYAML, binding.MIMETOML} {
+		content[mime] = &openapi.MediaType{Schema: openapi.Of[personPatch]()}
+	}
+	return content
+}
+
+func formatParameter() *openapi.Parameter {
+	names := make([]any, len(formats))
+	for i, f := range formats {
+		names[i] = f.name
+	}
+	return &openapi.Parameter{
+		Name: "format", In: "query", Description: "Overrides the Accept header",
+		Schema: &openapi.Schema{Type: "string", Enum: names},
+	}
+}
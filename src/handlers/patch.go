@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/jsonpatch"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// acceptPatch is advertised in the Accept-Patch header. Bodies of any
+// other supported format are read as a personPatch.
+var acceptPatch = strings.Join([]string{
+	jsonpatch.MIMEJSONPatch, jsonpatch.MIMEMergePatch, binding.MIMEJSON, binding.MIMEXML, binding.MIMEYAML, binding.MIMETOML,
+}, ", ")
+
+// personPatcher applies an already parsed PATCH body to person, writing an
+// error response and returning false if it cannot.
+type personPatcher func(ctx *gin.Context, person *Person) bool
+
+// bindPatch reads the PATCH body according to its content type.
+func bindPatch(ctx *gin.Context) (personPatcher, bool) {
+	switch ctx.ContentType() {
+	case jsonpatch.MIMEJSONPatch:
+		body, err := ctx.GetRawData()
+		if err != nil {
+			renderError(ctx, http.StatusBadRequest, err)
+			return nil, false
+		}
+		patch, err := jsonpatch.Decode(body)
+		if err != nil {
+			renderError(ctx, http.StatusBadRequest, err)
+			return nil, false
+		}
+		return documentPatch(patch.Apply), true
+	case jsonpatch.MIMEMergePatch:
+		body, err := ctx.GetRawData()
+		if err != nil {
+			renderError(ctx, http.StatusBadRequest, err)
+			return nil, false
+		}
+		return documentPatch(func(doc []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(doc, body)
+		}), true
+	default:
+		var patch personPatch
+		if err := ctx.ShouldBind(&patch); err != nil {
+			renderError(ctx, http.StatusBadRequest, err)
+			return nil, false
+		}
+		return func(_ *gin.Context, person *Person) bool {
+			if patch.FirstName != nil {
+				person.FirstName = *patch.FirstName
+			}
+			if patch.LastName != nil {
+				person.LastName = *patch.LastName
+			}
+			return true
+		}, true
+	}
+}
+
+// documentPatch applies a patch to the person's JSON representation and
+// decodes the result back. The patch may only change writable fields.
+func documentPatch(apply func(doc []byte) ([]byte, error)) personPatcher {
+	return func(ctx *gin.Context, person *Person) bool {
+		doc, err := json.Marshal(person)
+		if err != nil {
+			renderError(ctx, http.StatusInternalServerError, err)
+			return false
+		}
+		patched, err := apply(doc)
+		if err != nil {
+			patchError(ctx, err)
+			return false
+		}
+		var result Person
+		dec := json.NewDecoder(bytes.NewReader(patched))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&result); err != nil {
+			renderError(ctx, http.StatusUnprocessableEntity, fmt.Errorf("patched person is invalid: %w", err))
+			return false
+		}
+		if field := readOnlyChange(*person, result); field != "" {
+			renderError(ctx, http.StatusUnprocessableEntity, fmt.Errorf("%s is read-only", field))
+			return false
+		}
+		*person = result
+		return true
+	}
+}
+
+func readOnlyChange(before, after Person) string {
+	switch {
+	case after.ID != before.ID:
+		return "id"
+	case !after.CreatedAt.Equal(before.CreatedAt):
+		return "createdAt"
+	case after.CreatedBy != before.CreatedBy:
+		return "createdBy"
+	case !after.UpdatedAt.Equal(before.UpdatedAt):
+		return "updatedAt"
+	case after.Version != before.Version:
+		return "version"
+	}
+	return ""
+}
+
+// patchError maps patch failures onto RFC 5789's guidance: a broken patch
+// is a bad request, a failed test a conflict with the current state, and a
+// patch that does not fit the document unprocessable.
+func patchError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, jsonpatch.ErrMalformed):
+		renderError(ctx, http.StatusBadRequest, err)
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		renderError(ctx, http.StatusConflict, err)
+	default:
+		renderError(ctx, http.StatusUnprocessableEntity, err)
+	}
+}
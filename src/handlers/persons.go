@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/auth"
+	"github.com/faishalshidqi/gin-introductory-proj/src/logging"
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+	"github.com/faishalshidqi/gin-introductory-proj/src/store"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type PersonsHandler struct {
+	store  store.PersonStore
+	paging Paging
+}
+
+func NewPersonsHandler(s store.PersonStore, paging Paging) *PersonsHandler {
+	return &PersonsHandler{store: s, paging: paging}
+}
+
+// personPatch carries the optional fields of a PATCH body; nil means "leave
+// unchanged".
+type personPatch struct {
+	FirstName *string `json:"firstName" xml:"firstName,attr" yaml:"firstName" toml:"firstName"`
+	LastName  *string `json:"lastName" xml:"lastName,attr" yaml:"lastName" toml:"lastName"`
+}
+
+// ListPersonsHandler serves one page of the collection. See parsePersonQuery
+// for the filter, sort, limit and cursor parameters; further pages are
+// linked from the Link header.
+func (h *PersonsHandler) ListPersonsHandler(ctx *gin.Context) {
+	q, invalid := h.parsePersonQuery(ctx)
+	if len(invalid) > 0 {
+		invalidQuery(ctx, invalid)
+		return
+	}
+	persons, err := h.store.List(ctx)
+	if err != nil {
+		renderError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	page, hasPrev, hasNext := q.page(persons)
+	h.pageLinks(ctx, q, page, hasPrev, hasNext)
+	respond(ctx, http.StatusOK, models.PersonList{Persons: page}, binding.MIMEJSON)
+}
+
+func (h *PersonsHandler) GetPersonHandler(ctx *gin.Context) {
+	person, err := h.store.Get(ctx, ctx.Param("id"))
+	if err != nil {
+		storeError(ctx, err)
+		return
+	}
+	mimeType, ok := negotiateResponse(ctx, person, binding.MIMEJSON)
+	if !ok {
+		return
+	}
+	setValidators(ctx, person, mimeType)
+	ctx.Header("Accept-Patch", acceptPatch)
+	if notModified(ctx, person, mimeType) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	write(ctx, http.StatusOK, person, mimeType)
+}
+
+func (h *PersonsHandler) NewPersonHandler(ctx *gin.Context) {
+	var person Person
+	if err := bindPerson(ctx, &person); err != nil {
+		renderError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if !validPerson(ctx, &person) {
+		return
+	}
+	person.CreatedBy = ""
+	if principal := auth.PrincipalFromContext(ctx); principal != nil {
+		person.CreatedBy = principal.Subject
+	}
+	person, err := h.store.Create(ctx, person)
+	if err != nil {
+		storeError(ctx, err)
+		return
+	}
+	logging.FromContext(ctx).Info("person created", "person_id", person.ID)
+	ctx.Header("Location", "/persons/"+person.ID)
+	respondPerson(ctx, http.StatusCreated, person)
+}
+
+func (h *PersonsHandler) UpdatePersonHandler(ctx *gin.Context) {
+	var person Person
+	if err := bindPerson(ctx, &person); err != nil {
+		renderError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if !validPerson(ctx, &person) {
+		return
+	}
+	person.ID = ctx.Param("id")
+	person.Version = 0
+	if ctx.GetHeader("If-Match") != "" {
+		current, err := h.store.Get(ctx, person.ID)
+		if err != nil {
+			storeError(ctx, err)
+			return
+		}
+		version, ok := ifMatch(ctx, current)
+		if !ok {
+			return
+		}
+		person.Version = version
+	}
+	person, err := h.store.Update(ctx, person)
+	if err != nil {
+		storeError(ctx, err)
+		return
+	}
+	respondPerson(ctx, http.StatusOK, person)
+}
+
+// PatchPersonHandler accepts a JSON Patch, a JSON Merge Patch or a
+// personPatch in any supported format. The patched result is validated
+// again before it is stored.
+func (h *PersonsHandler) PatchPersonHandler(ctx *gin.Context) {
+	apply, ok := bindPatch(ctx)
+	if !ok {
+		return
+	}
+	person, err := h.store.Get(ctx, ctx.Param("id"))
+	if err != nil {
+		storeError(ctx, err)
+		return
+	}
+	if _, ok := ifMatch(ctx, person); !ok {
+		return
+	}
+	// The update is always conditional on the version read above so a
+	// concurrent write is never silently overwritten.
+	if !apply(ctx, &person) {
+		return
+	}
+	if !validPerson(ctx, &person) {
+		return
+	}
+	person, err = h.store.Update(ctx, person)
+	if err != nil {
+		storeError(ctx, err)
+		return
+	}
+	respondPerson(ctx, http.StatusOK, person)
+}
+
+func (h *PersonsHandler) DeletePersonHandler(ctx *gin.Context) {
+	var version int64
+	if ctx.GetHeader("If-Match") != "" {
+		current, err := h.store.Get(ctx, ctx.Param("id"))
+		if err != nil {
+			storeError(ctx, err)
+			return
+		}
+		v, ok := ifMatch(ctx, current)
+		if !ok {
+			return
+		}
+		version = v
+	}
+	if err := h.store.Delete(ctx, ctx.Param("id"), version); err != nil {
+		storeError(ctx, err)
+		return
+	}
+	logging.FromContext(ctx).Info("person deleted", "person_id", ctx.Param("id"))
+	ctx.Status(http.StatusNoContent)
+}
+
+// PersonOwner reports who created the person addressed by the :id parameter,
+// for ownership-scoped authorization.
+func (h *PersonsHandler) PersonOwner(ctx *gin.Context) (string, bool, error) {
+	person, err := h.store.Get(ctx, ctx.Param("id"))
+	if errors.Is(err, store.ErrNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return person.CreatedBy, true, nil
+}
+
+func storeError(ctx *gin.Context, err error) {
+	if errors.Is(err, store.ErrNotFound) {
+		renderError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if errors.Is(err, store.ErrConflict) {
+		// The record changed between our read and write: a failed
+		// precondition if the client sent one, a plain conflict if not.
+		code := http.StatusConflict
+		if ctx.GetHeader("If-Match") != "" {
+			code = http.StatusPreconditionFailed
+		}
+		renderError(ctx, code, err)
+		return
+	}
+	logging.FromContext(ctx).Error("store operation failed", "error", err)
+	renderError(ctx, http.StatusInternalServerError, err)
+}
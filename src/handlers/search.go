@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+	"github.com/faishalshidqi/gin-introductory-proj/src/problem"
+	"github.com/faishalshidqi/gin-introductory-proj/src/search"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type searchParams struct {
+	Query string `json:"q" validate:"required,max=200"`
+}
+
+type SearchHandler struct {
+	index  *search.Index
+	paging Paging
+}
+
+func NewSearchHandler(index *search.Index, paging Paging) *SearchHandler {
+	return &SearchHandler{index: index, paging: paging}
+}
+
+// SearchPersonsHandler answers GET /persons/search?q= with the best
+// matching persons first, in any supported format.
+func (h *SearchHandler) SearchPersonsHandler(ctx *gin.Context) {
+	params := searchParams{Query: ctx.Query("q")}
+	if !valid(ctx, &params) {
+		return
+	}
+	limit := h.paging.DefaultLimit
+	if raw := ctx.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > h.paging.MaxLimit {
+			invalidQuery(ctx, []problem.InvalidParam{{
+				Name:   "limit",
+				Reason: fmt.Sprintf("must be an integer between 1 and %d", h.paging.MaxLimit),
+			}})
+			return
+		}
+		limit = n
+	}
+
+	hits := h.index.Search(params.Query, limit)
+	persons := make([]Person, len(hits))
+	for i, hit := range hits {
+		persons[i] = hit.Person
+	}
+	respond(ctx, http.StatusOK, models.PersonList{Persons: persons}, binding.MIMEJSON)
+}
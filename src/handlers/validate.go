@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"github.com/faishalshidqi/gin-introductory-proj/src/i18n"
+	"github.com/faishalshidqi/gin-introductory-proj/src/problem"
+	"github.com/faishalshidqi/gin-introductory-proj/src/validation"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+type greetingParams struct {
+	Name string `json:"name" validate:"required,max=100,personname"`
+}
+
+func requestLanguage(ctx *gin.Context) language.Tag {
+	return i18n.Default().Negotiate(ctx.Query("lang"), ctx.GetHeader("Accept-Language"))
+}
+
+// validPerson normalizes the names of person and reports whether the result
+// passes validation, writing a problem response when it does not.
+func validPerson(ctx *gin.Context, person *Person) bool {
+	person.FirstName = validation.NormalizeName(person.FirstName)
+	person.LastName = validation.NormalizeName(person.LastName)
+	return valid(ctx, person)
+}
+
+func valid(ctx *gin.Context, obj any) bool {
+	params := validation.Struct(obj, requestLanguage(ctx))
+	if len(params) == 0 {
+		return true
+	}
+	problem.Write(ctx, validation.Problem(params))
+	return false
+}
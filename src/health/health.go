@@ -0,0 +1,114 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const checkTimeout = 2 * time.Second
+
+// Checker reports whether a dependency is usable.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Registry backs the liveness and readiness probes. Readiness fails while
+// the server is not accepting traffic or any registered check fails.
+type Registry struct {
+	mu     sync.RWMutex
+	checks map[string]Checker
+	ready  atomic.Bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{checks: make(map[string]Checker)}
+}
+
+func (r *Registry) Register(name string, c Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = c
+}
+
+func (r *Registry) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+func (r *Registry) Ready() bool {
+	return r.ready.Load()
+}
+
+type checkResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Run executes every registered check concurrently.
+func (r *Registry) Run(ctx context.Context) (map[string]checkResult, bool) {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	results := make(map[string]checkResult, len(names))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	healthy := true
+	for _, name := range names {
+		r.mu.RLock()
+		c := r.checks[name]
+		r.mu.RUnlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := checkResult{Status: "ok"}
+			if err := c.Check(ctx); err != nil {
+				res = checkResult{Status: "failing", Error: err.Error()}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = res
+			if res.Status != "ok" {
+				healthy = false
+			}
+		}()
+	}
+	wg.Wait()
+	return results, healthy
+}
+
+// LivenessHandler answers as long as the process can serve HTTP at all.
+func (r *Registry) LivenessHandler(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (r *Registry) ReadinessHandler(ctx *gin.Context) {
+	if !r.Ready() {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+		return
+	}
+	results, healthy := r.Run(ctx.Request.Context())
+	if !healthy {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": results})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok", "checks": results})
+}
@@ -0,0 +1,55 @@
+package health
+
+import (
+	"net/http"
+	"runtime/debug"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BuildTime may be injected at link time with
+// -ldflags "-X github.com/faishalshidqi/gin-introductory-proj/src/health.BuildTime=...";
+// otherwise the VCS commit time recorded by the Go toolchain is reported.
+var BuildTime string
+
+type BuildInfo struct {
+	Module    string `json:"module"`
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	Revision  string `json:"revision,omitempty"`
+	Modified  bool   `json:"modified"`
+	BuildTime string `json:"buildTime,omitempty"`
+}
+
+var readBuildInfo = sync.OnceValue(func() BuildInfo {
+	info := BuildInfo{Version: "(unknown)", BuildTime: BuildTime}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Module = bi.Main.Path
+	info.Version = bi.Main.Version
+	info.GoVersion = bi.GoVersion
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = s.Value
+			}
+		}
+	}
+	return info
+})
+
+func Build() BuildInfo {
+	return readBuildInfo()
+}
+
+func VersionHandler(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, Build())
+}
@@ -0,0 +1,156 @@
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed locales
+var locales embed.FS
+
+var Fallback = language.English
+
+// Catalog holds the messages of every loaded language. Lookups walk a
+// fallback chain from the requested tag through its parents (pt-BR -> pt)
+// and finally to Fallback.
+type Catalog struct {
+	matcher  language.Matcher
+	tags     []language.Tag
+	messages map[language.Tag]map[string]string
+}
+
+// Load reads every *.yaml, *.yml and *.toml file at the root of fsys. Each
+// file is named after the BCP 47 tag it provides and holds a flat map of
+// message keys to templates.
+func Load(fsys fs.FS) (*Catalog, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	c := &Catalog{messages: make(map[language.Tag]map[string]string)}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ext := path.Ext(entry.Name())
+		tag, err := language.Parse(strings.TrimSuffix(entry.Name(), ext))
+		if err != nil {
+			return nil, fmt.Errorf("i18n: %s: %w", entry.Name(), err)
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		messages := make(map[string]string)
+		switch ext {
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(data, &messages)
+		case ".toml":
+			err = toml.Unmarshal(data, &messages)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("i18n: %s: %w", entry.Name(), err)
+		}
+		c.messages[tag] = messages
+	}
+	if _, ok := c.messages[Fallback]; !ok {
+		return nil, fmt.Errorf("i18n: no catalog for fallback language %s", Fallback)
+	}
+
+	// The matcher treats its first tag as the default. The rest are sorted
+	// because the matcher breaks ties by position, and map order would
+	// change the result from run to run.
+	for tag := range c.messages {
+		if tag != Fallback {
+			c.tags = append(c.tags, tag)
+		}
+	}
+	slices.SortFunc(c.tags, func(a, b language.Tag) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	c.tags = slices.Insert(c.tags, 0, Fallback)
+	c.matcher = language.NewMatcher(c.tags)
+	return c, nil
+}
+
+var defaultCatalog = mustLoadEmbedded()
+
+func mustLoadEmbedded() *Catalog {
+	sub, err := fs.Sub(locales, "locales")
+	if err != nil {
+		panic(err)
+	}
+	c, err := Load(sub)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Default returns the catalog compiled into the binary.
+func Default() *Catalog {
+	return defaultCatalog
+}
+
+// Negotiate picks the best supported language. An explicit lang (from a
+// ?lang= parameter) takes precedence over the Accept-Language header.
+//
+// The first desired tag with a catalog of its own or of an ancestor wins,
+// so pt and pt-PT get pt rather than the matcher's pick of pt-BR. Only
+// when no desired tag has one does the matcher look for a close relative.
+func (c *Catalog) Negotiate(lang, acceptLanguage string) language.Tag {
+	var desired []language.Tag
+	if lang != "" {
+		if tag, err := language.Parse(lang); err == nil {
+			desired = append(desired, tag)
+		}
+	}
+	if accepted, _, err := language.ParseAcceptLanguage(acceptLanguage); err == nil {
+		desired = append(desired, accepted...)
+	}
+	for _, tag := range desired {
+		for t := tag; t != language.Und; t = t.Parent() {
+			if _, ok := c.messages[t]; ok {
+				return t
+			}
+		}
+	}
+	_, index, confidence := c.matcher.Match(desired...)
+	if confidence == language.No {
+		return Fallback
+	}
+	return c.tags[index]
+}
+
+// Message returns the template for key together with the language that
+// actually supplied it, which may be an ancestor of tag or Fallback.
+func (c *Catalog) Message(tag language.Tag, key string) (string, language.Tag) {
+	for t := tag; ; t = t.Parent() {
+		if msg, ok := c.messages[t][key]; ok {
+			return msg, t
+		}
+		if t == language.Und {
+			break
+		}
+	}
+	return c.messages[Fallback][key], Fallback
+}
+
+// Format looks up key like Message and substitutes {placeholder} fields.
+func (c *Catalog) Format(tag language.Tag, key string, args map[string]string) (string, language.Tag) {
+	msg, source := c.Message(tag, key)
+	for name, value := range args {
+		msg = strings.ReplaceAll(msg, "{"+name+"}", value)
+	}
+	return msg, source
+}
@@ -0,0 +1,41 @@
+package i18n
+
+import (
+	"io/fs"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		lang, accept string
+		want         string
+	}{
+		{"", "", "en"},
+		{"", "pt", "pt"},
+		{"", "pt-PT", "pt"},
+		{"", "pt-BR", "pt-BR"},
+		{"", "en-GB", "en"},
+		{"", "zh-TW", "zh-Hant"},
+		{"", "zh-CN", "zh-Hans"},
+		{"", "fr-CA, de", "fr"},
+		{"", "de-CH;q=0.2, ja;q=0.9", "ja"},
+		{"de", "fr", "de"},
+		{"not a tag", "es", "es"},
+	}
+	sub, err := fs.Sub(locales, "locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		// Repeat to catch a result that depends on map iteration order.
+		for range 5 {
+			c, err := Load(sub)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.Negotiate(tt.lang, tt.accept).String(); got != tt.want {
+				t.Fatalf("Negotiate(%q, %q) = %s, want %s", tt.lang, tt.accept, got, tt.want)
+			}
+		}
+	}
+}
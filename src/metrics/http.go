@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HTTPMetrics instruments gin routes by route template rather than raw path
+// so that /:name does not create one series per distinct name.
+type HTTPMetrics struct {
+	requests *CounterVec
+	duration *HistogramVec
+	inFlight *GaugeVec
+}
+
+func NewHTTPMetrics(r *Registry) *HTTPMetrics {
+	return &HTTPMetrics{
+		requests: r.NewCounterVec("http_requests_total",
+			"Total HTTP requests by route template, method and status class.",
+			"route", "method", "status_class"),
+		duration: r.NewHistogramVec("http_request_duration_seconds",
+			"HTTP request latency by route template, method and status class.",
+			DefaultBuckets, "route", "method", "status_class"),
+		inFlight: r.NewGaugeVec("http_requests_in_flight",
+			"HTTP requests currently being served by route template and method.",
+			"route", "method"),
+	}
+}
+
+func (m *HTTPMetrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		start := time.Now()
+		m.inFlight.Inc(route, method)
+		defer m.inFlight.Dec(route, method)
+
+		c.Next()
+
+		class := strconv.Itoa(c.Writer.Status()/100) + "xx"
+		m.requests.Inc(route, method, class)
+		m.duration.Observe(time.Since(start).Seconds(), route, method, class)
+	}
+}
+
+// Handler serves the registry in the Prometheus text format.
+func (r *Registry) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", ContentType)
+		c.Status(http.StatusOK)
+		if err := r.WriteText(c.Writer); err != nil {
+			c.Error(err)
+		}
+	}
+}
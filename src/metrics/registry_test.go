@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"bytes"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestWriteTextGolden(t *testing.T) {
+	r := NewRegistry()
+
+	requests := r.NewCounterVec("test_requests_total", "Requests by path.\nSecond line with a \\ backslash.", "method", "path")
+	requests.Inc("GET", "/")
+	requests.Add(2, "GET", "/")
+	requests.Inc("POST", `/a"quoted"\path`)
+	requests.Inc("GET", "/line\nbreak")
+
+	inFlight := r.NewGaugeVec("test_in_flight", "Requests being served.")
+	inFlight.Inc()
+	inFlight.Inc()
+	inFlight.Dec()
+	temperature := r.NewGaugeVec("test_temperature_celsius", "Readings by room.", "room")
+	temperature.Set(-3.5, "cellar")
+	temperature.Set(21, "attic")
+
+	r.NewGaugeFunc("test_ratio", "Always a half.", func() float64 { return 0.5 })
+	r.NewCounterFunc("test_forever_total", "Never stops.", func() float64 { return math.Inf(1) })
+
+	// Buckets are given out of order; an observation on a bound falls in
+	// that bucket, and one above every bound only in +Inf.
+	latency := r.NewHistogramVec("test_latency_seconds", "Latency.", []float64{1, 0.005, 0.25}, "route")
+	for _, v := range []float64{0.005, 0.1, 0.25, 0.3, 2} {
+		latency.Observe(v, "/persons")
+	}
+	latency.Observe(0.001, `/x"y`)
+	sizes := r.NewHistogramVec("test_size_bytes", "Unlabelled.", []float64{100, 1000})
+	sizes.Observe(1e6)
+
+	var got bytes.Buffer
+	if err := r.WriteText(&got); err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "writetext.golden")
+	if *update {
+		if err := os.WriteFile(golden, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("WriteText differs from %s (run with -update after checking):\n%s", golden, got.String())
+	}
+}
+
+func TestDuplicateMetricPanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("dup", "first")
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a name twice did not panic")
+		}
+	}()
+	r.NewGaugeVec("dup", "second")
+}
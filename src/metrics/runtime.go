@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// RegisterRuntime adds Go runtime and process gauges sampled at scrape time.
+func RegisterRuntime(r *Registry) {
+	start := float64(time.Now().Unix())
+	stats := &memStats{}
+
+	r.NewGaugeFunc("go_goroutines", "Number of goroutines that currently exist.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+	r.NewGaugeFunc("go_memstats_alloc_bytes", "Bytes of allocated heap objects.", func() float64 {
+		return float64(stats.read().HeapAlloc)
+	})
+	r.NewGaugeFunc("go_memstats_heap_inuse_bytes", "Bytes in in-use heap spans.", func() float64 {
+		return float64(stats.read().HeapInuse)
+	})
+	r.NewGaugeFunc("go_memstats_heap_objects", "Number of allocated heap objects.", func() float64 {
+		return float64(stats.read().HeapObjects)
+	})
+	r.NewGaugeFunc("go_memstats_sys_bytes", "Bytes of memory obtained from the OS.", func() float64 {
+		return float64(stats.read().Sys)
+	})
+	r.NewCounterFunc("go_memstats_mallocs_total", "Total heap objects allocated.", func() float64 {
+		return float64(stats.read().Mallocs)
+	})
+	r.NewCounterFunc("go_gc_cycles_total", "Completed GC cycles.", func() float64 {
+		return float64(stats.read().NumGC)
+	})
+	r.NewCounterFunc("go_gc_pause_seconds_total", "Cumulative stop-the-world GC pause time.", func() float64 {
+		return float64(stats.read().PauseTotalNs) / 1e9
+	})
+	r.NewGaugeFunc("go_gomaxprocs", "Value of GOMAXPROCS.", func() float64 {
+		return float64(runtime.GOMAXPROCS(0))
+	})
+	r.NewGaugeFunc("process_start_time_seconds", "Start time of the process since the Unix epoch.", func() float64 {
+		return start
+	})
+	info := r.NewGaugeVec("go_info", "Information about the Go environment.", "version")
+	info.Set(1, runtime.Version())
+}
+
+// memStats caches runtime.ReadMemStats, which stops the world, so that a
+// single scrape reads it once rather than once per gauge.
+type memStats struct {
+	mu   sync.Mutex
+	at   time.Time
+	last runtime.MemStats
+}
+
+func (m *memStats) read() runtime.MemStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if time.Since(m.at) > time.Second {
+		runtime.ReadMemStats(&m.last)
+		m.at = time.Now()
+	}
+	return m.last
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"encoding/xml"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+type Greeting struct {
+	XMLName xml.Name `json:"-" xml:"greeting" yaml:"-" toml:"-"`
+	Message string   `json:"message" xml:"message" yaml:"message" toml:"message"`
+}
+
+func (g Greeting) Proto() proto.Message {
+	return &pb.Greeting{Message: g.Message}
+}
@@ -0,0 +1,3 @@
+package pb
+
+//go:generate protoc -I ../../.. --go_out=../../.. --go_opt=paths=source_relative src/models/pb/person.proto
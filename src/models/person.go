@@ -0,0 +1,35 @@
+package models
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type Person struct {
+	XMLName   xml.Name  `json:"-" xml:"person" yaml:"-" toml:"-"`
+	ID        string    `json:"id" xml:"id,attr,omitempty" yaml:"id" toml:"id" readonly:"true"`
+	FirstName string    `json:"firstName" xml:"firstName,attr" yaml:"firstName" toml:"firstName" validate:"required,max=100,personname"`
+	LastName  string    `json:"lastName" xml:"lastName,attr" yaml:"lastName" toml:"lastName" validate:"required,max=100,personname"`
+	CreatedAt time.Time `json:"createdAt" xml:"createdAt,attr" yaml:"createdAt" toml:"createdAt" readonly:"true"`
+	CreatedBy string    `json:"createdBy,omitempty" xml:"createdBy,attr,omitempty" yaml:"createdBy,omitempty" toml:"createdBy,omitempty" readonly:"true" doc:"Subject of the principal that created the person"`
+	UpdatedAt time.Time `json:"updatedAt" xml:"updatedAt,attr" yaml:"updatedAt" toml:"updatedAt" readonly:"true"`
+	// Version starts at 1 and increases with every update; it backs the
+	// ETag used for conditional requests.
+	Version int64 `json:"version" xml:"version,attr" yaml:"version" toml:"version" readonly:"true" doc:"Starts at 1 and increases with every update"`
+}
+
+// SamplePerson is the fixed person served at GET /person. It keeps the
+// shape that route had before persons were stored, a name and nothing
+// else, so none of the stored fields show up empty.
+type SamplePerson struct {
+	XMLName   xml.Name `json:"-" xml:"person" yaml:"-" toml:"-"`
+	FirstName string   `json:"firstName" xml:"firstName,attr" yaml:"firstName" toml:"firstName"`
+	LastName  string   `json:"lastName" xml:"lastName,attr" yaml:"lastName" toml:"lastName"`
+}
+
+// PersonList wraps a collection so that every output format, XML and TOML
+// included, gets a single root element.
+type PersonList struct {
+	XMLName xml.Name `json:"-" xml:"persons" yaml:"-" toml:"-"`
+	Persons []Person `json:"persons" xml:"person" yaml:"persons" toml:"persons"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"github.com/faishalshidqi/gin-introductory-proj/src/models/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (p Person) Proto() proto.Message {
+	return p.ToProto()
+}
+
+func (p Person) ToProto() *pb.Person {
+	msg := &pb.Person{
+		Id:        p.ID,
+		FirstName: p.FirstName,
+		LastName:  p.LastName,
+		CreatedBy: p.CreatedBy,
+		Version:   p.Version,
+	}
+	if !p.CreatedAt.IsZero() {
+		msg.CreatedAt = timestamppb.New(p.CreatedAt)
+	}
+	if !p.UpdatedAt.IsZero() {
+		msg.UpdatedAt = timestamppb.New(p.UpdatedAt)
+	}
+	return msg
+}
+
+func (p SamplePerson) Proto() proto.Message {
+	return &pb.Person{FirstName: p.FirstName, LastName: p.LastName}
+}
+
+func PersonFromProto(msg *pb.Person) Person {
+	p := Person{
+		ID:        msg.GetId(),
+		FirstName: msg.GetFirstName(),
+		LastName:  msg.GetLastName(),
+		CreatedBy: msg.GetCreatedBy(),
+		Version:   msg.GetVersion(),
+	}
+	if msg.CreatedAt != nil {
+		p.CreatedAt = msg.CreatedAt.AsTime()
+	}
+	if msg.UpdatedAt != nil {
+		p.UpdatedAt = msg.UpdatedAt.AsTime()
+	}
+	return p
+}
+
+func (l PersonList) Proto() proto.Message {
+	msg := &pb.PersonList{Persons: make([]*pb.Person, len(l.Persons))}
+	for i, p := range l.Persons {
+		msg.Persons[i] = p.ToProto()
+	}
+	return msg
+}
+
+func PersonListFromProto(msg *pb.PersonList) PersonList {
+	l := PersonList{Persons: make([]Person, len(msg.GetPersons()))}
+	for i, p := range msg.GetPersons() {
+		l.Persons[i] = PersonFromProto(p)
+	}
+	return l
+}
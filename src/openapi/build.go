@@ -0,0 +1,203 @@
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Build documents every route in routes with the operations in ops, keyed
+// by method and gin path as in "GET /persons/:id". An operation with a
+// Route is keyed by its documented path instead and served by that route.
+// HEAD routes without an operation of their own reuse the GET one.
+//
+// Build fails when a route has no operation or an operation has no route,
+// so the document cannot drift from the router without the server
+// refusing to start.
+func Build(info Info, routes gin.RoutesInfo, ops map[string]*Operation) (*Document, error) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+		routes:  make(map[string][]*Operation),
+	}
+	registered := make(map[string]bool)
+	for _, route := range routes {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	var errs []error
+	covered := make(map[string]bool)
+	keys := make([]string, 0, len(ops))
+	for key := range ops {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		op := ops[key]
+		method, path, _ := strings.Cut(key, " ")
+		route := path
+		if op.Route != "" {
+			route = op.Route
+		}
+		if !registered[method+" "+route] {
+			errs = append(errs, fmt.Errorf("openapi: operation %s has no route", key))
+			continue
+		}
+		covered[method+" "+route] = true
+		if op.Route == "" {
+			op.Parameters = append(pathParameters(path, op.Parameters), op.Parameters...)
+		}
+		doc.add(method, path, op)
+		doc.routes[method+" "+route] = append(doc.routes[method+" "+route], op)
+	}
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		if covered[key] {
+			continue
+		}
+		if get, ok := ops["GET "+route.Path]; ok && route.Method == "HEAD" {
+			head := headOf(get)
+			doc.add(route.Method, route.Path, head)
+			doc.routes[key] = []*Operation{head}
+			continue
+		}
+		errs = append(errs, fmt.Errorf("openapi: route %s is not documented", key))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	gen := newGenerator()
+	for _, item := range doc.Paths {
+		for _, op := range *item {
+			gen.resolveOperation(op)
+		}
+	}
+	doc.Components.Schemas = gen.schemas
+	return doc, nil
+}
+
+func (d *Document) add(method, path string, op *Operation) {
+	op.path = templatePath(path)
+	item := d.Paths[op.path]
+	if item == nil {
+		item = &PathItem{}
+		d.Paths[op.path] = item
+	}
+	(*item)[strings.ToLower(method)] = op
+}
+
+// operation finds the operation for a request served by the gin route,
+// preferring one documented at the request's exact path.
+func (d *Document) operation(method, route, path string) *Operation {
+	var fallback *Operation
+	for _, op := range d.routes[method+" "+route] {
+		if op.Route == "" {
+			fallback = op
+		} else if op.path == path {
+			return op
+		}
+	}
+	return fallback
+}
+
+func (g *generator) resolveOperation(op *Operation) {
+	for _, p := range op.Parameters {
+		p.Schema = g.resolve(p.Schema)
+	}
+	if op.RequestBody != nil {
+		for _, mt := range op.RequestBody.Content {
+			mt.Schema = g.resolve(mt.Schema)
+		}
+	}
+	for _, resp := range op.Responses {
+		for _, mt := range resp.Content {
+			mt.Schema = g.resolve(mt.Schema)
+		}
+		for _, h := range resp.Headers {
+			h.Schema = g.resolve(h.Schema)
+		}
+	}
+}
+
+// Secure declares bearer authentication, API key authentication or both,
+// and requires one of them on every operation that is not Public.
+func (d *Document) Secure(bearer, apiKeys bool) {
+	d.Components.SecuritySchemes = make(map[string]*SecurityScheme)
+	var requirements []SecurityRequirement
+	if bearer {
+		d.Components.SecuritySchemes["bearerAuth"] = &SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"}
+		requirements = append(requirements, SecurityRequirement{"bearerAuth": {}})
+	}
+	if apiKeys {
+		d.Components.SecuritySchemes["apiKey"] = &SecurityScheme{
+			Type:        "apiKey",
+			In:          "header",
+			Name:        "X-API-Key",
+			Description: "Also accepted as \"Authorization: ApiKey <key>\".",
+		}
+		requirements = append(requirements, SecurityRequirement{"apiKey": {}})
+	}
+	for _, item := range d.Paths {
+		for _, op := range *item {
+			if !op.Public {
+				op.Security = requirements
+			}
+		}
+	}
+}
+
+// headOf describes HEAD as GET without response bodies.
+func headOf(get *Operation) *Operation {
+	head := *get
+	if head.OperationID != "" {
+		head.OperationID = "head" + exported(head.OperationID)
+	}
+	head.Responses = make(map[string]*Response, len(get.Responses))
+	for code, resp := range get.Responses {
+		head.Responses[code] = &Response{Description: resp.Description, Headers: resp.Headers}
+	}
+	head.Parameters = append([]*Parameter(nil), get.Parameters...)
+	return &head
+}
+
+// templatePath turns gin's /persons/:id into OpenAPI's /persons/{id}.
+func templatePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if len(seg) > 1 && (seg[0] == ':' || seg[0] == '*') {
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// pathParameters declares the route's path parameters that declared does
+// not already describe.
+func pathParameters(path string, declared []*Parameter) []*Parameter {
+	var params []*Parameter
+	for _, seg := range strings.Split(path, "/") {
+		if len(seg) < 2 || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+		name := seg[1:]
+		if hasParameter(declared, name, "path") {
+			continue
+		}
+		params = append(params, &Parameter{Name: name, In: "path", Required: true, Schema: String()})
+	}
+	return params
+}
+
+func hasParameter(params []*Parameter, name, in string) bool {
+	for _, p := range params {
+		if p.Name == name && p.In == in {
+			return true
+		}
+	}
+	return false
+}
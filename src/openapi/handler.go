@@ -0,0 +1,62 @@
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+const MIMEYAML = "application/yaml"
+
+//go:embed docs.html
+var docsPage []byte
+
+// Served holds a document marshalled once for its handlers. The handlers
+// can be routed before Load, which is what lets the document describe
+// its own routes.
+type Served struct {
+	json []byte
+	yaml []byte
+}
+
+func (s *Served) Load(doc *Document) error {
+	js, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Round-trip through JSON so the YAML uses the same field names and
+	// omissions without a second set of tags to keep in step.
+	var generic any
+	if err := json.Unmarshal(js, &generic); err != nil {
+		return err
+	}
+	var ym bytes.Buffer
+	enc := yaml.NewEncoder(&ym)
+	enc.SetIndent(2)
+	if err := enc.Encode(generic); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	s.json, s.yaml = js, ym.Bytes()
+	return nil
+}
+
+func (s *Served) JSONHandler(c *gin.Context) {
+	c.Data(http.StatusOK, gin.MIMEJSON, s.json)
+}
+
+func (s *Served) YAMLHandler(c *gin.Context) {
+	c.Data(http.StatusOK, MIMEYAML, s.yaml)
+}
+
+// DocsHandler serves an interactive page that renders /openapi.json and
+// can send requests to the documented operations.
+func DocsHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+}
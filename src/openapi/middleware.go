@@ -0,0 +1,331 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/logging"
+	"github.com/faishalshidqi/gin-introductory-proj/src/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// TypeContractViolation is the problem type of a response that does not
+// match the document.
+const TypeContractViolation = "/problems/contract-violation"
+
+// DefaultMaxBodyBytes is the largest request body a Validator reads when
+// none is configured.
+const DefaultMaxBodyBytes = 1 << 20
+
+// Validator checks requests, and optionally responses, against the
+// document. Like Served it can be installed before the routes it checks
+// exist and is loaded once they do.
+type Validator struct {
+	Requests bool
+	// Responses replaces a JSON or XML response that does not match the
+	// document with a 500 contract violation. It buffers those bodies and
+	// is meant for development and test deployments.
+	Responses bool
+	// MaxBodyBytes caps the request bodies Requests reads, DefaultMaxBodyBytes
+	// when zero. Operations with a StreamedBody are never read.
+	MaxBodyBytes int64
+
+	doc *Document
+}
+
+func (v *Validator) Load(doc *Document) {
+	v.doc = doc
+}
+
+// Middleware rejects requests whose parameters or body do not match the
+// operation with a 400 validation problem whose invalid-params point at
+// the offending values, a 413 for bodies over MaxBodyBytes and a 415 for
+// undocumented body types. Routes
+// without an operation, such as 404s, pass through.
+func (v *Validator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if v.doc == nil {
+			c.Next()
+			return
+		}
+		op := v.doc.operation(c.Request.Method, c.FullPath(), c.Request.URL.Path)
+		if op == nil {
+			c.Next()
+			return
+		}
+		if v.Requests {
+			if p := v.checkRequest(c, op); p != nil {
+				problem.Abort(c, p)
+				return
+			}
+		}
+		if !v.Responses || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		v.finishResponse(c, op, w)
+	}
+}
+
+func (v *Validator) checkRequest(c *gin.Context, op *Operation) *problem.Problem {
+	var invalid []problem.InvalidParam
+	query := c.Request.URL.Query()
+	for _, p := range op.Parameters {
+		var raw string
+		var present bool
+		switch p.In {
+		case "path":
+			raw = c.Param(p.Name)
+			present = raw != ""
+		case "query":
+			raw, present = query.Get(p.Name), query.Has(p.Name)
+		case "header":
+			raw = c.GetHeader(p.Name)
+			present = raw != ""
+		}
+		if !present {
+			if p.Required {
+				invalid = append(invalid, problem.InvalidParam{Name: p.Name, Reason: "is required"})
+			}
+			continue
+		}
+		for _, bad := range v.doc.validate(p.Schema, v.doc.scalarValue(p.Schema, raw), "", false) {
+			invalid = append(invalid, problem.InvalidParam{Name: p.Name, Reason: bad.Reason})
+		}
+	}
+
+	if body := op.RequestBody; body != nil && !op.StreamedBody {
+		p, bodyInvalid := v.checkBody(c, body)
+		if p != nil {
+			return p
+		}
+		invalid = append(invalid, bodyInvalid...)
+	}
+	if len(invalid) == 0 {
+		return nil
+	}
+	p := problem.New(http.StatusBadRequest, "the request does not match the API contract")
+	p.Type = problem.TypeValidation
+	p.InvalidParams = invalid
+	return p
+}
+
+func (v *Validator) checkBody(c *gin.Context, body *RequestBody) (*problem.Problem, []problem.InvalidParam) {
+	mediaType := c.ContentType()
+	if mediaType == "" && c.Request.ContentLength == 0 {
+		if body.Required {
+			return nil, []problem.InvalidParam{{Name: "body", Pointer: "#", Reason: "is required"}}
+		}
+		return nil, nil
+	}
+	content, ok := body.Content[mediaType]
+	if !ok {
+		supported := make([]string, 0, len(body.Content))
+		for t := range body.Content {
+			supported = append(supported, t)
+		}
+		return problem.New(http.StatusUnsupportedMediaType, "unsupported content type "+strconv.Quote(mediaType)).
+			With("supported", supported), nil
+	}
+	if !isJSON(mediaType) && !isXML(mediaType) {
+		return nil, nil
+	}
+	limit := v.MaxBodyBytes
+	if limit <= 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	if c.Request.ContentLength > limit {
+		return tooLarge(limit), nil
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+	if err != nil {
+		var maxBytes *http.MaxBytesError
+		if errors.As(err, &maxBytes) {
+			return tooLarge(limit), nil
+		}
+		return problem.New(http.StatusBadRequest, err.Error()), nil
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+	if len(data) == 0 && !body.Required {
+		return nil, nil
+	}
+	violations, err := v.doc.check(content.Schema, mediaType, data, false)
+	if err != nil {
+		return nil, []problem.InvalidParam{{Name: "body", Pointer: "#", Reason: err.Error()}}
+	}
+	return nil, invalidParams(violations)
+}
+
+func tooLarge(limit int64) *problem.Problem {
+	return problem.New(http.StatusRequestEntityTooLarge, fmt.Sprintf("the body is larger than %d bytes", limit)).
+		With("max_bytes", limit)
+}
+
+// check decodes a JSON or XML body and validates it against s.
+func (d *Document) check(s *Schema, mediaType string, data []byte, response bool) ([]violation, error) {
+	var value any
+	if isXML(mediaType) {
+		decoded, rootViolations, err := d.decodeXML(data, s)
+		if err != nil || len(rootViolations) > 0 {
+			return rootViolations, err
+		}
+		value = decoded
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+	}
+	return d.validate(s, value, "", response), nil
+}
+
+func (v *Validator) finishResponse(c *gin.Context, op *Operation, w *bufferedWriter) {
+	if !w.decided {
+		w.ResponseWriter.WriteHeader(w.status)
+		return
+	}
+	if !w.buffering {
+		return
+	}
+	violations := v.checkResponse(op, w)
+	if len(violations) == 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+		w.ResponseWriter.Write(w.body.Bytes())
+		return
+	}
+	logging.FromContext(c).Error("response does not match the API contract",
+		"status", w.status, "pointer", "#"+violations[0].Pointer, "reason", violations[0].Reason,
+		"violations", len(violations))
+	header := w.ResponseWriter.Header()
+	for _, name := range []string{"Content-Length", "ETag", "Last-Modified", "Location"} {
+		header.Del(name)
+	}
+	p := problem.New(http.StatusInternalServerError, fmt.Sprintf("the %d response does not match the API contract", w.status))
+	p.Type = TypeContractViolation
+	p.InvalidParams = invalidParams(violations)
+	problem.Write(c, p)
+}
+
+func (v *Validator) checkResponse(op *Operation, w *bufferedWriter) []violation {
+	// Server errors are already reported as failures; checking their
+	// shape adds nothing.
+	if w.status >= http.StatusInternalServerError {
+		return nil
+	}
+	resp, ok := op.Responses[strconv.Itoa(w.status)]
+	if !ok {
+		resp, ok = op.Responses["default"]
+	}
+	if !ok {
+		return []violation{{Reason: fmt.Sprintf("status %d is not documented", w.status)}}
+	}
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	content, ok := resp.Content[mediaType]
+	if !ok {
+		return []violation{{Reason: fmt.Sprintf("content type %q is not documented for status %d", mediaType, w.status)}}
+	}
+	violations, err := v.doc.check(content.Schema, mediaType, w.body.Bytes(), true)
+	if err != nil {
+		return []violation{{Reason: err.Error()}}
+	}
+	return violations
+}
+
+func invalidParams(violations []violation) []problem.InvalidParam {
+	params := make([]problem.InvalidParam, len(violations))
+	for i, bad := range violations {
+		name := strings.TrimPrefix(bad.Pointer, "/")
+		if name == "" {
+			name = "body"
+		}
+		params[i] = problem.InvalidParam{Name: name, Pointer: "#" + bad.Pointer, Reason: bad.Reason}
+	}
+	return params
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == gin.MIMEJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+func isXML(mediaType string) bool {
+	return mediaType == gin.MIMEXML || mediaType == gin.MIMEXML2 || strings.HasSuffix(mediaType, "+xml")
+}
+
+// bufferedWriter holds back JSON and XML bodies until they have been
+// checked. Other bodies, such as streamed exports, go straight through.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status    int
+	decided   bool
+	buffering bool
+	body      bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.decide()
+}
+
+func (w *bufferedWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	w.buffering = isJSON(mediaType) || isXML(mediaType)
+	if !w.buffering {
+		w.ResponseWriter.WriteHeader(w.status)
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.decide()
+	if w.buffering {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if w.buffering {
+		return w.body.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.decided
+}
+
+func (w *bufferedWriter) Flush() {
+	if w.decided && !w.buffering {
+		w.ResponseWriter.Flush()
+	}
+}
@@ -0,0 +1,119 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestValidatorBodyLimit(t *testing.T) {
+	const limit = 64
+	item := &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"name": String()},
+		Required:   []string{"name"},
+	}
+	body := func(schema *Schema) *RequestBody {
+		return &RequestBody{Required: true, Content: map[string]*MediaType{gin.MIMEJSON: {Schema: schema}}}
+	}
+	ops := map[string]*Operation{
+		"POST /items": {
+			RequestBody: body(item),
+			Responses:   map[string]*Response{"200": {Description: "Bytes read"}},
+		},
+		"POST /stream": {
+			RequestBody:  body(&Schema{Type: "array", Items: item}),
+			StreamedBody: true,
+			Responses:    map[string]*Response{"200": {Description: "Bytes read"}},
+		},
+	}
+
+	v := &Validator{Requests: true, MaxBodyBytes: limit}
+	router := gin.New()
+	router.Use(v.Middleware())
+	// Both handlers answer with the number of body bytes they could read.
+	count := func(c *gin.Context) {
+		n, err := io.Copy(io.Discard, c.Request.Body)
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.String(http.StatusOK, strconv.FormatInt(n, 10))
+	}
+	router.POST("/items", count)
+	router.POST("/stream", count)
+	doc, err := Build(Info{Title: "test", Version: "1"}, router.Routes(), ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Load(doc)
+
+	// padded is a valid item of exactly n bytes.
+	padded := func(n int) string {
+		const prefix, suffix = `{"name":"`, `"}`
+		return prefix + strings.Repeat("x", n-len(prefix)-len(suffix)) + suffix
+	}
+	many := "[" + strings.Repeat(padded(limit)+",", 99) + padded(limit) + "]"
+
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		chunked bool
+		want    int
+	}{
+		{name: "small", path: "/items", body: padded(20), want: http.StatusOK},
+		{name: "at the limit", path: "/items", body: padded(limit), want: http.StatusOK},
+		{name: "over the limit", path: "/items", body: padded(limit + 1), want: http.StatusRequestEntityTooLarge},
+		{name: "chunked over the limit", path: "/items", body: padded(limit + 1), chunked: true, want: http.StatusRequestEntityTooLarge},
+		{name: "streamed", path: "/stream", body: many, want: http.StatusOK},
+		{name: "streamed chunked", path: "/stream", body: many, chunked: true, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r io.Reader = strings.NewReader(tt.body)
+			if tt.chunked {
+				// Hides the length, as a chunked request does.
+				r = io.MultiReader(r)
+			}
+			req := httptest.NewRequest(http.MethodPost, tt.path, r)
+			req.Header.Set("Content-Type", gin.MIMEJSON)
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusOK {
+				var p struct {
+					Status   int   `json:"status"`
+					MaxBytes int64 `json:"max_bytes"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+					t.Fatal(err)
+				}
+				if p.Status != tt.want || p.MaxBytes != limit {
+					t.Errorf("problem = %+v, want status %d and max_bytes %d", p, tt.want, limit)
+				}
+				return
+			}
+			// The handler must see the whole body, replayed or streamed.
+			if got := rec.Body.String(); got != strconv.Itoa(len(tt.body)) {
+				t.Errorf("handler read %s bytes, want %d", got, len(tt.body))
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package openapi
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	timeType     = reflect.TypeFor[time.Time]()
+	durationType = reflect.TypeFor[time.Duration]()
+	rawType      = reflect.TypeFor[json.RawMessage]()
+	xmlNameType  = reflect.TypeFor[xml.Name]()
+)
+
+// generator turns Go types into schemas, placing every named struct in
+// components so it is described once and referenced everywhere.
+//
+// Struct fields are read through their tags: json names the property,
+// xml marks attributes and element names, validate contributes required,
+// min and max, and doc supplies the description.
+type generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newGenerator() *generator {
+	return &generator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// resolve replaces an Of placeholder with the generated schema and
+// descends into hand-written schemas to do the same.
+func (g *generator) resolve(s *Schema) *Schema {
+	if s == nil {
+		return nil
+	}
+	if s.goType != nil && s.property != "" {
+		if prop, ok := g.object(s.goType).Properties[s.property]; ok {
+			return prop
+		}
+		return &Schema{}
+	}
+	if s.goType != nil {
+		generated := g.schema(s.goType)
+		if s.Description != "" {
+			generated.Description = s.Description
+		}
+		return generated
+	}
+	s.Items = g.resolve(s.Items)
+	s.AdditionalProperties = g.resolve(s.AdditionalProperties)
+	for k, p := range s.Properties {
+		s.Properties[k] = g.resolve(p)
+	}
+	for i, o := range s.OneOf {
+		s.OneOf[i] = g.resolve(o)
+	}
+	return s
+}
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "string", Description: "a Go duration such as 1m30s"}
+	case rawType:
+		return &Schema{}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.component(t)}
+	}
+	// Interfaces and anything else accept any JSON value.
+	return &Schema{}
+}
+
+// component registers the schema for the named struct t and returns its
+// component name.
+func (g *generator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := exported(t.Name())
+	if _, taken := g.schemas[name]; taken {
+		name = exported(pkgName(t)) + name
+	}
+	g.names[t] = name
+	g.schemas[name] = &Schema{} // reserve the name for recursive types
+	*g.schemas[name] = *g.object(t)
+	return name
+}
+
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.fields(t, s)
+	return s
+}
+
+func (g *generator) fields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		jsonName, jsonOpts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		xmlName, xmlOpts, _ := strings.Cut(f.Tag.Get("xml"), ",")
+		if f.Type == xmlNameType {
+			if xmlName != "" {
+				s.XML = &XML{Name: xmlName}
+			}
+			continue
+		}
+		if f.Anonymous && jsonName == "" && f.Type.Kind() == reflect.Struct {
+			g.fields(f.Type, s)
+			continue
+		}
+		if !f.IsExported() || jsonName == "-" {
+			continue
+		}
+		if jsonName == "" {
+			jsonName = f.Name
+		}
+
+		prop := g.schema(f.Type)
+		if nilable(f.Type) && !strings.Contains(jsonOpts, "omitempty") {
+			// encoding/json writes a nil slice, map or pointer as null.
+			prop = nullable(prop)
+		}
+		if prop.Ref != "" && (f.Tag.Get("doc") != "" || strings.Contains(xmlOpts, "attr")) {
+			// A $ref cannot carry siblings reliably in every tool; wrap it.
+			prop = &Schema{OneOf: []*Schema{prop}}
+		}
+		if doc := f.Tag.Get("doc"); doc != "" {
+			prop.Description = doc
+		}
+		if strings.Contains(xmlOpts, "attr") {
+			prop.XML = &XML{Attribute: true}
+		}
+		if xmlName != "" && xmlName != jsonName && xmlName != "-" {
+			if prop.XML == nil {
+				prop.XML = &XML{}
+			}
+			prop.XML.Name = xmlName
+		}
+		if f.Tag.Get("readonly") == "true" {
+			prop.ReadOnly = true
+		}
+		if applyValidate(prop, f.Tag.Get("validate")) {
+			s.Required = append(s.Required, jsonName)
+		}
+		s.Properties[jsonName] = prop
+	}
+}
+
+// applyValidate maps the validator rules that have a JSON Schema
+// counterpart and reports whether the field is required.
+func applyValidate(s *Schema, tag string) bool {
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		if name == "dive" {
+			break
+		}
+		n, err := strconv.Atoi(arg)
+		switch {
+		case name == "required":
+			required = true
+		case name == "personname":
+			if s.Description == "" {
+				s.Description = "letters, spaces, hyphens and apostrophes"
+			}
+		case err != nil:
+		case name == "max" && s.Type == "string":
+			s.MaxLength = &n
+		case name == "min" && s.Type == "string":
+			s.MinLength = &n
+		case name == "max" && s.Type == "array":
+			s.MaxItems = &n
+		case name == "min" && s.Type == "array":
+			s.MinItems = &n
+		case name == "max":
+			f := float64(n)
+			s.Maximum = &f
+		case name == "min":
+			f := float64(n)
+			s.Minimum = &f
+		}
+	}
+	return required
+}
+
+func nilable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Map:
+		return true
+	case reflect.Slice:
+		return t != rawType && t.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+func nullable(s *Schema) *Schema {
+	if t, ok := s.Type.(string); ok {
+		s.Type = []string{t, "null"}
+		return s
+	}
+	if s.Type == nil && s.Ref == "" {
+		return s // already accepts anything
+	}
+	return &Schema{OneOf: []*Schema{s, {Type: "null"}}}
+}
+
+func exported(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+func pkgName(t reflect.Type) string {
+	path := t.PkgPath()
+	return path[strings.LastIndex(path, "/")+1:]
+}
@@ -0,0 +1,157 @@
+package openapi
+
+import (
+	"reflect"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/problem"
+)
+
+const Version = "3.1.0"
+
+// Document is an OpenAPI 3.1 document, restricted to the parts this
+// service describes.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+
+	// routes maps "METHOD /gin/path" to the operations it serves.
+	routes map[string][]*Operation
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case HTTP methods to operations.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+
+	// Route is the gin route serving the operation when it is not the
+	// documented path, as for custom methods like /persons:import that
+	// are served through /:name.
+	Route string `json:"-"`
+	// path is the documented path, set by Build.
+	path string
+	// Public operations stay unauthenticated when Secure is applied.
+	Public bool `json:"-"`
+	// StreamedBody marks request bodies too large to buffer; the handler
+	// checks them record by record and Validator leaves them alone.
+	StreamedBody bool `json:"-"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+type SecurityRequirement map[string][]string
+
+// Schema is a JSON Schema 2020-12 object as used by OpenAPI 3.1. Type is a
+// string or, for nullable values, a list of strings.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+	XML                  *XML               `json:"xml,omitempty"`
+
+	// goType is resolved into a component reference by Build, or into
+	// one of its properties when property is set.
+	goType   reflect.Type
+	property string
+}
+
+type XML struct {
+	Name      string `json:"name,omitempty"`
+	Attribute bool   `json:"attribute,omitempty"`
+	Wrapped   bool   `json:"wrapped,omitempty"`
+}
+
+// Of stands for the schema generated from T; see Build.
+func Of[T any]() *Schema {
+	return &Schema{goType: reflect.TypeFor[T]()}
+}
+
+// String, Integer and Boolean are shorthand for simple parameter schemas.
+func String() *Schema  { return &Schema{Type: "string"} }
+func Integer() *Schema { return &Schema{Type: "integer"} }
+func Boolean() *Schema { return &Schema{Type: "boolean"} }
+
+// Property stands for the schema of one property of a struct schema made
+// by Of, so parameters can share the rules of a request type's field.
+func (s *Schema) Property(name string) *Schema {
+	return &Schema{goType: s.goType, property: name}
+}
+
+// ProblemResponse is an error response with an RFC 7807 problem body.
+func ProblemResponse(description string) *Response {
+	return &Response{
+		Description: description,
+		Content:     map[string]*MediaType{problem.ContentType: {Schema: Of[problem.Problem]()}},
+	}
+}
@@ -0,0 +1,290 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// violation is one place where a value does not match its schema.
+// Pointer is an RFC 6901 JSON Pointer into the validated document.
+type violation struct {
+	Pointer string
+	Reason  string
+}
+
+// resolveRef follows a local $ref into the document's components.
+func (d *Document) resolveRef(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/")
+		if !ok {
+			return &Schema{}
+		}
+		s = d.Components.Schemas[name]
+	}
+	if s == nil {
+		return &Schema{}
+	}
+	return s
+}
+
+// unwrap resolves s and looks through a oneOf that only wraps a single
+// schema or makes one nullable, as the generator writes for fields.
+func (d *Document) unwrap(s *Schema) *Schema {
+	s = d.resolveRef(s)
+	var inner *Schema
+	for _, alt := range s.OneOf {
+		if t, _ := alt.Type.(string); t == "null" {
+			continue
+		}
+		if inner != nil {
+			return s
+		}
+		inner = alt
+	}
+	if inner == nil {
+		return s
+	}
+	return d.unwrap(inner)
+}
+
+// validate checks v, a document decoded with json.Decoder.UseNumber, or
+// built by decodeXML or scalarValue, against s. Properties marked
+// readOnly are only required in responses.
+func (d *Document) validate(s *Schema, v any, ptr string, response bool) []violation {
+	s = d.resolveRef(s)
+	var out []violation
+	fail := func(format string, args ...any) {
+		out = append(out, violation{Pointer: ptr, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.OneOf) > 0 {
+		matched := 0
+		for _, alt := range s.OneOf {
+			if len(d.validate(alt, v, ptr, response)) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			if len(s.OneOf) == 1 {
+				return d.validate(s.OneOf[0], v, ptr, response)
+			}
+			fail("must match exactly one of %d schemas, matches %d", len(s.OneOf), matched)
+		}
+	}
+	if types := schemaTypes(s); len(types) > 0 && !hasType(types, v) {
+		names := make([]string, len(types))
+		for i, t := range types {
+			names[i] = typeNames[t]
+		}
+		fail("must be %s", strings.Join(names, " or "))
+		return out
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		fail("must be one of %s", enumList(s.Enum))
+	}
+
+	switch v := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("must be at least %d characters long", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("must be at most %d characters long", *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(v) {
+				fail("must match %s", s.Pattern)
+			}
+		}
+		if reason := checkFormat(s.Format, v); reason != "" {
+			fail("%s", reason)
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			fail("must be at most %v", *s.Maximum)
+		}
+		if s.Format == "int32" && (f < math.MinInt32 || f > math.MaxInt32) {
+			fail("must fit in 32 bits")
+		}
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				out = append(out, d.validate(s.Items, item, ptr+"/"+strconv.Itoa(i), response)...)
+			}
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; ok {
+				continue
+			}
+			if prop := d.resolveRef(s.Properties[name]); prop.ReadOnly && !response {
+				continue
+			}
+			out = append(out, violation{Pointer: ptr + "/" + escapePointer(name), Reason: "is required"})
+		}
+		for name, value := range v {
+			prop, known := s.Properties[name]
+			if !known {
+				prop = s.AdditionalProperties
+			}
+			if prop != nil {
+				out = append(out, d.validate(prop, value, ptr+"/"+escapePointer(name), response)...)
+			}
+		}
+	}
+	return out
+}
+
+var typeNames = map[string]string{
+	"null":    "null",
+	"boolean": "a boolean",
+	"string":  "a string",
+	"integer": "an integer",
+	"number":  "a number",
+	"array":   "an array",
+	"object":  "an object",
+}
+
+func schemaTypes(s *Schema) []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	case []any:
+		var types []string
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func hasType(types []string, v any) bool {
+	for _, t := range types {
+		switch v := v.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case json.Number:
+			if t == "number" || (t == "integer" && isInteger(v)) {
+				return true
+			}
+		case []any:
+			if t == "array" {
+				return true
+			}
+		case map[string]any:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isInteger(n json.Number) bool {
+	r, ok := new(big.Rat).SetString(n.String())
+	return ok && r.IsInt()
+}
+
+func inEnum(enum []any, v any) bool {
+	got, err := json.Marshal(v)
+	if err != nil {
+		return false
+	}
+	for _, e := range enum {
+		if want, err := json.Marshal(e); err == nil && bytes.Equal(got, want) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumList(enum []any) string {
+	items := make([]string, len(enum))
+	for i, e := range enum {
+		items[i] = fmt.Sprint(e)
+	}
+	return strings.Join(items, ", ")
+}
+
+func checkFormat(format, v string) string {
+	switch format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+			return "must be an RFC 3339 date-time"
+		}
+	case "byte":
+		if _, err := base64.StdEncoding.DecodeString(v); err != nil {
+			return "must be base64 encoded"
+		}
+	}
+	return ""
+}
+
+// scalarValue converts text from a parameter or an XML document to the
+// JSON value s expects. Text that does not convert stays a string so
+// validation reports the type mismatch.
+func (d *Document) scalarValue(s *Schema, text string) any {
+	s = d.unwrap(s)
+	for _, t := range schemaTypes(s) {
+		switch t {
+		case "integer", "number":
+			if isNumber(text) {
+				return json.Number(text)
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(text); err == nil {
+				return b
+			}
+		}
+	}
+	return text
+}
+
+// isNumber reports whether text is a JSON number; ParseFloat alone also
+// accepts forms like Inf and 0x1p-2.
+func isNumber(text string) bool {
+	if text == "" || (text[0] != '-' && (text[0] < '0' || text[0] > '9')) {
+		return false
+	}
+	var n json.Number
+	return json.Unmarshal([]byte(text), &n) == nil
+}
+
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
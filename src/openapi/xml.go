@@ -0,0 +1,139 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// element is a parsed XML element, kept generic so it can be read through
+// a schema the way encoding/xml reads it through struct tags.
+type element struct {
+	name     string
+	attrs    []xml.Attr
+	children []*element
+	text     strings.Builder
+}
+
+func parseXML(data []byte) (*element, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*element
+	var root *element
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			el := &element{name: tok.Name.Local, attrs: tok.Attr}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, el)
+			} else if root == nil {
+				root = el
+			}
+			stack = append(stack, el)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(tok)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("no root element")
+	}
+	return root, nil
+}
+
+// decodeXML reads an XML document into the JSON shape s describes, so
+// the same validation applies to both encodings. Attributes and elements
+// the schema does not mention are skipped, as encoding/xml skips them.
+func (d *Document) decodeXML(data []byte, s *Schema) (any, []violation, error) {
+	root, err := parseXML(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	s = d.resolveRef(s)
+	if s.XML != nil && s.XML.Name != "" && root.name != s.XML.Name {
+		return nil, []violation{{Reason: fmt.Sprintf("root element must be <%s>", s.XML.Name)}}, nil
+	}
+	return d.xmlValue(root, s), nil, nil
+}
+
+func (d *Document) xmlValue(el *element, s *Schema) any {
+	s = d.unwrap(s)
+	types := schemaTypes(s)
+	if len(s.Properties) == 0 && (len(types) == 0 || types[0] != "object") {
+		return d.scalarValue(s, el.text.String())
+	}
+
+	obj := make(map[string]any)
+	for name, prop := range s.Properties {
+		resolved := d.unwrap(prop)
+		xmlName, attribute, wrapped := name, false, false
+		if prop.XML != nil {
+			if prop.XML.Name != "" {
+				xmlName = prop.XML.Name
+			}
+			attribute, wrapped = prop.XML.Attribute, prop.XML.Wrapped
+		}
+		switch {
+		case attribute:
+			for _, a := range el.attrs {
+				if a.Name.Local == xmlName {
+					obj[name] = d.scalarValue(prop, a.Value)
+				}
+			}
+		case len(schemaTypes(resolved)) > 0 && schemaTypes(resolved)[0] == "array":
+			var items []*element
+			if wrapped {
+				if wrapper := el.child(xmlName); wrapper != nil {
+					items = wrapper.children
+				}
+			} else {
+				items = el.all(xmlName)
+			}
+			if len(items) == 0 && !wrapped {
+				continue
+			}
+			values := make([]any, len(items))
+			for i, item := range items {
+				values[i] = d.xmlValue(item, resolved.Items)
+			}
+			obj[name] = values
+		default:
+			if child := el.child(xmlName); child != nil {
+				obj[name] = d.xmlValue(child, prop)
+			}
+		}
+	}
+	return obj
+}
+
+func (el *element) child(name string) *element {
+	for _, c := range el.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func (el *element) all(name string) []*element {
+	var matches []*element
+	for _, c := range el.children {
+		if c.name == name {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
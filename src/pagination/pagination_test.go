@@ -0,0 +1,86 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSignerRoundTrip(t *testing.T) {
+	s := NewSigner("secret")
+	tests := []Cursor{
+		{},
+		{Values: []string{"2024-01-02T03:04:05Z", "abc"}, Query: Fingerprint("sort=created_at")},
+		{Values: []string{"Lovelace", "a.b"}, Before: true, Query: Fingerprint("sort=last_name&q=ada")},
+	}
+	for _, want := range tests {
+		got, err := s.Decode(s.Encode(want))
+		if err != nil {
+			t.Fatalf("Decode(Encode(%+v)): %v", want, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("round trip = %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestSignerRejectsBadTokens(t *testing.T) {
+	s := NewSigner("secret")
+	token := s.Encode(Cursor{Values: []string{"abc"}, Query: Fingerprint("sort=id")})
+	payload, mac, _ := strings.Cut(token, ".")
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	tests := []struct {
+		name, token string
+	}{
+		{"empty", ""},
+		{"no separator", payload + mac},
+		{"payload not base64", "!!." + mac},
+		{"mac not base64", payload + ".!!"},
+		{"tampered payload", b64([]byte(`{"v":["abd"],"q":"x"}`)) + "." + mac},
+		{"truncated mac", payload + "." + mac[:len(mac)-2]},
+		{"other secret", NewSigner("other").Encode(Cursor{Values: []string{"abc"}})},
+		{"random key", NewSigner("").Encode(Cursor{Values: []string{"abc"}})},
+		{"signed payload not JSON", forge(s, []byte("not json"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Decode(tt.token); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Decode error = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a, b := Fingerprint("sort=id"), Fingerprint("sort=id")
+	if a != b {
+		t.Errorf("Fingerprint is not stable: %q != %q", a, b)
+	}
+	if Fingerprint("sort=-id") == a {
+		t.Error("different queries share a fingerprint")
+	}
+	if len(a) != 16 {
+		t.Errorf("len(Fingerprint) = %d, want 16", len(a))
+	}
+}
+
+func TestLink(t *testing.T) {
+	u, err := url.Parse("https://example.com/persons?cursor=a.b&limit=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<https://example.com/persons?cursor=a.b&limit=10>; rel="next"`
+	if got := Link(u, "next"); got != want {
+		t.Errorf("Link = %s, want %s", got, want)
+	}
+}
+
+// forge signs an arbitrary payload with s's key.
+func forge(s *Signer, payload []byte) string {
+	b64 := base64.RawURLEncoding.EncodeToString
+	return b64(payload) + "." + b64(s.mac(payload))
+}
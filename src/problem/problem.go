@@ -0,0 +1,88 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ContentType = "application/problem+json"
+
+const (
+	TypeValidation = "/problems/validation-error"
+)
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+	Extensions    map[string]any `json:"-"`
+}
+
+// InvalidParam describes one failing field of a request. Pointer, when
+// set, locates the field in the body as an RFC 6901 JSON Pointer fragment.
+type InvalidParam struct {
+	Name    string `json:"name" xml:"name,attr"`
+	Pointer string `json:"pointer,omitempty" xml:"pointer,attr,omitempty"`
+	Reason  string `json:"reason" xml:"reason,attr"`
+}
+
+// New returns an about:blank problem for status.
+func New(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// With adds an extension member to the serialized problem.
+func (p *Problem) With(key string, value any) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]any)
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type plain Problem
+	base, err := json.Marshal((*plain)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return base, err
+	}
+	members := make(map[string]any, len(p.Extensions))
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+	if err := json.Unmarshal(base, &members); err != nil {
+		return nil, err
+	}
+	return json.Marshal(members)
+}
+
+// Write renders p as application/problem+json, filling in the request path
+// as the instance when none was set.
+func Write(ctx *gin.Context, p *Problem) {
+	if p.Instance == "" && ctx.Request != nil {
+		p.Instance = ctx.Request.URL.Path
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+	ctx.Data(p.Status, ContentType, body)
+}
+
+// Abort writes p and stops the handler chain.
+func Abort(ctx *gin.Context, p *Problem) {
+	Write(ctx, p)
+	ctx.Abort()
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// full is when the bucket will have refilled, after which it is
+	// indistinguishable from a new one and can be dropped.
+	full time.Time
+}
+
+// MemoryBackend keeps buckets in process memory. Buckets that have refilled
+// are dropped periodically so idle clients cost nothing.
+type MemoryBackend struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	m := &MemoryBackend{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go m.sweep()
+	return m
+}
+
+func (m *MemoryBackend) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	now := m.now()
+	burst := float64(limit.Burst)
+
+	m.mu.Lock()
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		m.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+	res := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((burst - b.tokens) / limit.Rate)
+	b.full = now.Add(res.Reset)
+	m.mu.Unlock()
+	return res, nil
+}
+
+func (m *MemoryBackend) sweep() {
+	defer close(m.done)
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		}
+		now := m.now()
+		m.mu.Lock()
+		for key, b := range m.buckets {
+			if !now.Before(b.full) {
+				delete(m.buckets, key)
+			}
+		}
+		m.mu.Unlock()
+	}
+}
+
+// Close stops the background sweeper.
+func (m *MemoryBackend) Close() error {
+	close(m.stop)
+	<-m.done
+	return nil
+}
@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryBackendRefill(t *testing.T) {
+	m := NewMemoryBackend()
+	defer m.Close()
+	now := time.Unix(1_700_000_000, 0)
+	m.now = func() time.Time { return now }
+	limit := Limit{Rate: 2, Burst: 3}
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		want    Result
+	}{
+		{"full bucket", 0, Result{Allowed: true, Limit: 3, Remaining: 2, Reset: 500 * time.Millisecond}},
+		{"burst", 0, Result{Allowed: true, Limit: 3, Remaining: 1, Reset: time.Second}},
+		{"burst spent", 0, Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 1500 * time.Millisecond}},
+		{"empty", 0, Result{Limit: 3, Remaining: 0, Reset: 1500 * time.Millisecond, RetryAfter: 500 * time.Millisecond}},
+		{"half a token", 250 * time.Millisecond, Result{Limit: 3, Remaining: 0, Reset: 1250 * time.Millisecond, RetryAfter: 250 * time.Millisecond}},
+		{"one token", 250 * time.Millisecond, Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 1500 * time.Millisecond}},
+		{"refill stops at burst", time.Hour, Result{Allowed: true, Limit: 3, Remaining: 2, Reset: 500 * time.Millisecond}},
+	}
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		got, err := m.Take(context.Background(), "k", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != step.want {
+			t.Errorf("%s: Take = %+v, want %+v", step.name, got, step.want)
+		}
+	}
+
+	if got, _ := m.Take(context.Background(), "other", limit); got.Remaining != 2 {
+		t.Errorf("keys share a bucket: %+v", got)
+	}
+}
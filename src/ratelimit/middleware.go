@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/auth"
+	"github.com/faishalshidqi/gin-introductory-proj/src/logging"
+	"github.com/faishalshidqi/gin-introductory-proj/src/problem"
+	"github.com/gin-gonic/gin"
+)
+
+const TypeRateLimited = "/problems/rate-limited"
+
+// Limits holds the limits of authenticated clients: requests made with an
+// API key are limited per key, other authenticated requests per user.
+type Limits struct {
+	APIKey Limit
+	User   Limit
+}
+
+// ByIP takes a token from the client IP's bucket for every request. Mount
+// it before authentication, so that requests with bad or missing
+// credentials are throttled too. Authenticated requests pass through it as
+// well, and every client behind a NAT or proxy shares one address, so limit
+// should sit well above those of Limits.
+// gin's ClientIP only trusts X-Forwarded-For from the configured trusted
+// proxies.
+func ByIP(backend Backend, limit Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		take(c, backend, "ip:"+c.ClientIP(), limit)
+	}
+}
+
+// ByPrincipal takes a token from the bucket of the authenticated API key
+// or user. Mount it after authentication; anonymous requests pass
+// untouched, ByIP having limited them already.
+func ByPrincipal(backend Backend, limits Limits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := auth.PrincipalFromContext(c)
+		switch {
+		case p == nil:
+			c.Next()
+		case p.Method == auth.MethodAPIKey:
+			take(c, backend, p.Subject, limits.APIKey)
+		default:
+			take(c, backend, "user:"+p.Issuer+":"+p.Subject, limits.User)
+		}
+	}
+}
+
+// take answers 429 once the bucket under key is empty. The RateLimit
+// headers describe the last bucket a request went through, the most
+// specific one. Backend failures are logged and let the request through
+// rather than turning an outage of the limiter into one of the API.
+func take(c *gin.Context, backend Backend, key string, limit Limit) {
+	if limit.Unlimited() {
+		c.Next()
+		return
+	}
+	res, err := backend.Take(c, key, limit)
+	if err != nil {
+		logging.FromContext(c).Error("rate limit backend failed", "error", err)
+		c.Next()
+		return
+	}
+
+	h := c.Writer.Header()
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Burst, ceilSeconds(limit.Window())))
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+	if !res.Allowed {
+		h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+		p := problem.New(http.StatusTooManyRequests, "rate limit exceeded")
+		p.Type = TypeRateLimited
+		problem.Abort(c, p)
+		return
+	}
+	c.Next()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,151 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/auth"
+	"github.com/gin-gonic/gin"
+)
+
+type failingBackend struct{}
+
+func (failingBackend) Take(context.Context, string, Limit) (Result, error) {
+	return Result{}, errors.New("backend down")
+}
+
+func newTestRouter(handlers ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		// ?key= and ?user= stand in for an authenticated API key and user.
+		if key := c.Query("key"); key != "" {
+			auth.SetPrincipal(c, &auth.Principal{Subject: "apikey:" + key, Method: auth.MethodAPIKey})
+		} else if user := c.Query("user"); user != "" {
+			auth.SetPrincipal(c, &auth.Principal{Subject: user, Issuer: "issuer", Method: auth.MethodJWT})
+		}
+	})
+	router.Use(handlers...)
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func get(router *gin.Engine, target, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.RemoteAddr = ip + ":1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestByIPHeaders(t *testing.T) {
+	m := NewMemoryBackend()
+	defer m.Close()
+	now := time.Unix(1_700_000_000, 0)
+	m.now = func() time.Time { return now }
+	router := newTestRouter(ByIP(m, Limit{Rate: 0.5, Burst: 2}))
+
+	tests := []struct {
+		status    int
+		remaining string
+		reset     string
+		retry     string
+	}{
+		{http.StatusOK, "1", "2", ""},
+		{http.StatusOK, "0", "4", ""},
+		{http.StatusTooManyRequests, "0", "4", "2"},
+	}
+	for i, tt := range tests {
+		w := get(router, "/", "192.0.2.1")
+		h := w.Header()
+		if w.Code != tt.status {
+			t.Fatalf("request %d: status = %d, want %d", i, w.Code, tt.status)
+		}
+		if got := h.Get("RateLimit-Policy"); got != "2;w=4" {
+			t.Errorf("request %d: RateLimit-Policy = %q, want 2;w=4", i, got)
+		}
+		if got := h.Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: RateLimit-Limit = %q, want 2", i, got)
+		}
+		if got := h.Get("RateLimit-Remaining"); got != tt.remaining {
+			t.Errorf("request %d: RateLimit-Remaining = %q, want %s", i, got, tt.remaining)
+		}
+		if got := h.Get("RateLimit-Reset"); got != tt.reset {
+			t.Errorf("request %d: RateLimit-Reset = %q, want %s", i, got, tt.reset)
+		}
+		if got := h.Get("Retry-After"); got != tt.retry {
+			t.Errorf("request %d: Retry-After = %q, want %q", i, got, tt.retry)
+		}
+	}
+	w := get(router, "/", "192.0.2.1")
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" || !strings.Contains(w.Body.String(), TypeRateLimited) {
+		t.Errorf("429 = %s %s, want a %s problem", ct, w.Body, TypeRateLimited)
+	}
+	if w := get(router, "/", "192.0.2.2"); w.Code != http.StatusOK {
+		t.Errorf("another IP was limited: %d", w.Code)
+	}
+	now = now.Add(2 * time.Second)
+	if w := get(router, "/", "192.0.2.1"); w.Code != http.StatusOK {
+		t.Errorf("status after refill = %d, want 200", w.Code)
+	}
+}
+
+func TestByPrincipal(t *testing.T) {
+	m := NewMemoryBackend()
+	defer m.Close()
+	limits := Limits{APIKey: Limit{Rate: 0.001, Burst: 2}, User: Limit{Rate: 0.001, Burst: 1}}
+	router := newTestRouter(ByPrincipal(m, limits))
+
+	steps := []struct {
+		target string
+		want   int
+	}{
+		{"/?key=a", http.StatusOK},
+		{"/?key=a", http.StatusOK},
+		{"/?key=a", http.StatusTooManyRequests},
+		{"/?key=b", http.StatusOK},
+		{"/?user=ada", http.StatusOK},
+		{"/?user=ada", http.StatusTooManyRequests},
+		{"/?user=grace", http.StatusOK},
+		// The API key's bucket is not the user's, even under one subject.
+		{"/?user=apikey:b", http.StatusOK},
+	}
+	for i, step := range steps {
+		if w := get(router, step.target, "192.0.2.1"); w.Code != step.want {
+			t.Errorf("step %d: GET %s = %d, want %d", i, step.target, w.Code, step.want)
+		}
+	}
+	for range 5 {
+		w := get(router, "/", "192.0.2.1")
+		if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("anonymous request was limited: %d %v", w.Code, w.Header())
+		}
+	}
+}
+
+func TestTakePassesThrough(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend Backend
+		limit   Limit
+	}{
+		{"unlimited", failingBackend{}, Limit{}},
+		{"zero burst", failingBackend{}, Limit{Rate: 1}},
+		{"backend failure", failingBackend{}, Limit{Rate: 1, Burst: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(ByIP(tt.backend, tt.limit))
+			for range 3 {
+				if w := get(router, "/", "192.0.2.1"); w.Code != http.StatusOK {
+					t.Fatalf("status = %d, want 200", w.Code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package search
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+)
+
+// Match scores. An exact term beats a prefix, which beats a typo.
+const (
+	scoreExact  = 1.0
+	scorePrefix = 0.5
+	scoreFuzzy  = 0.4
+)
+
+// Hit is one search result.
+type Hit struct {
+	Person models.Person
+	Score  float64
+}
+
+// Index is an in-memory inverted index over persons' first and last names.
+// It is safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[string]document
+	postings map[string]map[string]struct{}
+	// terms holds the keys of postings in order, for prefix lookups.
+	terms []string
+}
+
+type document struct {
+	person models.Person
+	terms  []string
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[string]document),
+		postings: make(map[string]map[string]struct{}),
+	}
+}
+
+// Put adds p or replaces the entry with the same ID.
+func (x *Index) Put(p models.Person) {
+	terms := Tokenize(p.FirstName + " " + p.LastName)
+	slices.Sort(terms)
+	terms = slices.Compact(terms)
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(p.ID)
+	x.docs[p.ID] = document{person: p, terms: terms}
+	for _, t := range terms {
+		ids, ok := x.postings[t]
+		if !ok {
+			ids = make(map[string]struct{})
+			x.postings[t] = ids
+			i, _ := slices.BinarySearch(x.terms, t)
+			x.terms = slices.Insert(x.terms, i, t)
+		}
+		ids[p.ID] = struct{}{}
+	}
+}
+
+func (x *Index) Remove(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(id)
+}
+
+func (x *Index) remove(id string) {
+	doc, ok := x.docs[id]
+	if !ok {
+		return
+	}
+	delete(x.docs, id)
+	for _, t := range doc.terms {
+		ids := x.postings[t]
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(x.postings, t)
+			if i, found := slices.BinarySearch(x.terms, t); found {
+				x.terms = slices.Delete(x.terms, i, i+1)
+			}
+		}
+	}
+}
+
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.docs)
+}
+
+// Search returns up to limit persons matching every term of query, best
+// first. A query term matches an indexed term exactly, as a prefix, or
+// within a small edit distance that grows with the term's length.
+func (x *Index) Search(query string, limit int) []Hit {
+	queryTerms := Tokenize(query)
+	if len(queryTerms) == 0 {
+		return nil
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	var scores map[string]float64
+	for _, qt := range queryTerms {
+		// Each document counts its best match for this query term.
+		best := make(map[string]float64)
+		for term, score := range x.candidates(qt) {
+			for id := range x.postings[term] {
+				best[id] = max(best[id], score)
+			}
+		}
+		if scores == nil {
+			scores = best
+			continue
+		}
+		for id, total := range scores {
+			if s, ok := best[id]; ok {
+				scores[id] = total + s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{Person: x.docs[id].person, Score: score})
+	}
+	slices.SortFunc(hits, func(a, b Hit) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		if c := strings.Compare(Fold(a.Person.LastName), Fold(b.Person.LastName)); c != 0 {
+			return c
+		}
+		if c := strings.Compare(Fold(a.Person.FirstName), Fold(b.Person.FirstName)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Person.ID, b.Person.ID)
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// candidates scores every indexed term that qt could refer to. The caller
+// holds the read lock.
+func (x *Index) candidates(qt string) map[string]float64 {
+	out := make(map[string]float64)
+	if _, ok := x.postings[qt]; ok {
+		out[qt] = scoreExact
+	}
+	qlen := len([]rune(qt))
+	for i, _ := slices.BinarySearch(x.terms, qt); i < len(x.terms) && strings.HasPrefix(x.terms[i], qt); i++ {
+		t := x.terms[i]
+		if t != qt {
+			// Shorter completions rank higher: "jo" is closer to "joe"
+			// than to "johansson".
+			out[t] = scorePrefix + 0.4*float64(qlen)/float64(len([]rune(t)))
+		}
+	}
+	if k := maxEdits(qlen); k > 0 {
+		for _, t := range x.terms {
+			if _, seen := out[t]; seen {
+				continue
+			}
+			if d := editDistance(qt, t, k); d <= k {
+				out[t] = scoreFuzzy / float64(d)
+			}
+		}
+	}
+	return out
+}
+
+// maxEdits tolerates one typo from three letters on and two from six, so
+// short terms do not match everything.
+func maxEdits(n int) int {
+	switch {
+	case n >= 6:
+		return 2
+	case n >= 3:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// editDistance is the Levenshtein distance between a and b, or k+1 as soon
+// as it is known to exceed k.
+func editDistance(a, b string, k int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > k || -d > k {
+		return k + 1
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > k {
+			return k + 1
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
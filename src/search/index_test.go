@@ -0,0 +1,96 @@
+package search
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+)
+
+func TestSearchRanking(t *testing.T) {
+	x := NewIndex()
+	for _, p := range []models.Person{
+		{ID: "1", FirstName: "Joe", LastName: "Smith"},
+		{ID: "2", FirstName: "John", LastName: "Johansson"},
+		{ID: "3", FirstName: "Ada", LastName: "Lovelace"},
+		{ID: "4", FirstName: "Mary-Jane", LastName: "O'Neil"},
+		{ID: "5", FirstName: "Björn", LastName: "Ståhl"},
+		{ID: "6", FirstName: "Joanna", LastName: "Smyth"},
+		{ID: "7", FirstName: "Ada", LastName: "Byron"},
+		{ID: "8", FirstName: "Jo", LastName: "March"},
+	} {
+		x.Put(p)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		{"empty query", "", 0, nil},
+		{"no match", "xyz", 0, nil},
+		{"exact beats typo", "smith", 0, []string{"1", "6"}},
+		{"exact beats prefix, shorter completions first", "jo", 0, []string{"8", "1", "2", "6"}},
+		{"limit", "jo", 2, []string{"8", "1"}},
+		{"ties by last name", "ada", 0, []string{"7", "3"}},
+		{"every term must match", "ada lovelace", 0, []string{"3"}},
+		{"diacritics and case are folded", "STAHL", 0, []string{"5"}},
+		{"hyphens and apostrophes split terms", "jane neil", 0, []string{"4"}},
+		{"two typos in a long term", "lovelsae", 0, []string{"3"}},
+		{"no typos in a short term", "jp", 0, nil},
+		{"equal typos tie by last name", "jon", 0, []string{"2", "8", "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, hit := range x.Search(tt.query, tt.limit) {
+				got = append(got, hit.Person.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexPutAndRemove(t *testing.T) {
+	x := NewIndex()
+	x.Put(models.Person{ID: "1", FirstName: "Ada", LastName: "Byron"})
+	x.Put(models.Person{ID: "1", FirstName: "Ada", LastName: "Lovelace"})
+	if n := x.Len(); n != 1 {
+		t.Fatalf("Len = %d after replacing, want 1", n)
+	}
+	if hits := x.Search("byron", 0); len(hits) != 0 {
+		t.Errorf("the replaced name still matches: %v", hits)
+	}
+	if hits := x.Search("lovelace", 0); len(hits) != 1 {
+		t.Errorf("the new name does not match: %v", hits)
+	}
+	x.Remove("1")
+	if hits := x.Search("ada", 0); len(hits) != 0 || x.Len() != 0 {
+		t.Errorf("removed person still indexed: %v", hits)
+	}
+	if len(x.terms) != 0 || len(x.postings) != 0 {
+		t.Errorf("terms left behind: %v", x.terms)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Mary-Jane O'Neil", []string{"mary", "jane", "o", "neil"}},
+		{"Ståhl STAHL", []string{"stahl", "stahl"}},
+		{"Łukasz Øster", []string{"lukasz", "oster"}},
+		{"Ægir Þór", []string{"aegir", "thor"}},
+		{"Straße", []string{"strasse"}},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// letters that carry no combining mark to strip but are commonly typed as
+// their base letter.
+var baseLetters = strings.NewReplacer(
+	"ø", "o", "ł", "l", "đ", "d", "ð", "d", "þ", "th", "æ", "ae", "œ", "oe", "ı", "i",
+)
+
+// Fold reduces s to the form terms are indexed under: case-folded, with
+// diacritics removed, so "Ståhl", "STAHL" and "stahl" are one term.
+func Fold(s string) string {
+	// Transformers and Casers keep state, so every call builds its own.
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	s, _, err := transform.String(t, s)
+	if err != nil {
+		return ""
+	}
+	return baseLetters.Replace(cases.Fold().String(s))
+}
+
+// Tokenize folds s and splits it into terms on anything that is not a
+// letter or digit, so "Mary-Jane O'Neil" yields mary, jane, o and neil.
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(Fold(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
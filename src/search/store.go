@@ -0,0 +1,77 @@
+package search
+
+import (
+	"context"
+	"sync"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+	"github.com/faishalshidqi/gin-introductory-proj/src/store"
+)
+
+// IndexedStore keeps an Index in step with the wrapped store: every
+// successful write is applied to the index as well. Writes are serialized
+// so the index sees them in the order the store committed them.
+type IndexedStore struct {
+	mu    sync.Mutex
+	inner store.PersonStore
+	index *Index
+}
+
+// WithIndex loads every person in inner into index and returns the wrapper
+// that maintains it from then on.
+func WithIndex(ctx context.Context, inner store.PersonStore, index *Index) (*IndexedStore, error) {
+	persons, err := inner.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range persons {
+		index.Put(p)
+	}
+	return &IndexedStore{inner: inner, index: index}, nil
+}
+
+func (s *IndexedStore) List(ctx context.Context) ([]models.Person, error) {
+	return s.inner.List(ctx)
+}
+
+func (s *IndexedStore) ListPage(ctx context.Context, after models.Person, limit int) ([]models.Person, error) {
+	return s.inner.ListPage(ctx, after, limit)
+}
+
+func (s *IndexedStore) Get(ctx context.Context, id string) (models.Person, error) {
+	return s.inner.Get(ctx, id)
+}
+
+func (s *IndexedStore) Create(ctx context.Context, person models.Person) (models.Person, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	person, err := s.inner.Create(ctx, person)
+	if err == nil {
+		s.index.Put(person)
+	}
+	return person, err
+}
+
+func (s *IndexedStore) Update(ctx context.Context, person models.Person) (models.Person, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	person, err := s.inner.Update(ctx, person)
+	if err == nil {
+		s.index.Put(person)
+	}
+	return person, err
+}
+
+func (s *IndexedStore) Delete(ctx context.Context, id string, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.inner.Delete(ctx, id, version)
+	if err == nil {
+		s.index.Remove(id)
+	}
+	return err
+}
+
+func (s *IndexedStore) Ping(ctx context.Context) error {
+	return store.Ping(ctx, s.inner)
+}
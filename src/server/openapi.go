@@ -0,0 +1,162 @@
+package server
+
+import (
+	"maps"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/handlers"
+	"github.com/faishalshidqi/gin-introductory-proj/src/health"
+	"github.com/faishalshidqi/gin-introductory-proj/src/metrics"
+	"github.com/faishalshidqi/gin-introductory-proj/src/openapi"
+)
+
+// document builds the OpenAPI document for the routes registered by
+// routes. Every route must be described, so New fails instead of serving
+// a document that has drifted from the router.
+func (s *Server) document() (*openapi.Document, error) {
+	ops := serverOperations()
+	if s.Metrics != nil {
+		ops["GET /metrics"] = &openapi.Operation{
+			OperationID: "metrics",
+			Summary:     "Prometheus metrics",
+			Tags:        []string{"operations"},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "Metrics in the text exposition format", Content: map[string]*openapi.MediaType{
+					metrics.ContentType: {Schema: openapi.String()},
+				}},
+			},
+			Public: true,
+		}
+	}
+	api := handlers.IndexOperations()
+	if s.Config.Features.LegacyPerson {
+		maps.Copy(api, handlers.PersonOperations())
+	}
+	if s.Config.Features.Persons {
+		maps.Copy(api, handlers.PersonsOperations())
+	}
+	if s.APIKeys != nil {
+		maps.Copy(api, handlers.APIKeysOperations())
+	}
+	for _, op := range api {
+		s.middlewareResponses(op)
+	}
+	maps.Copy(ops, api)
+
+	doc, err := openapi.Build(openapi.Info{
+		Title:   "gin-introductory-proj",
+		Version: health.Build().Version,
+	}, s.Router.Routes(), ops)
+	if err != nil {
+		return nil, err
+	}
+	if s.authenticates() {
+		doc.Secure(s.Verifier != nil, s.APIKeys != nil)
+	}
+	return doc, nil
+}
+
+// middlewareResponses documents the errors the middleware in front of the
+// handlers can answer with.
+func (s *Server) middlewareResponses(op *openapi.Operation) {
+	add := func(code, description string) {
+		if _, ok := op.Responses[code]; !ok {
+			op.Responses[code] = openapi.ProblemResponse(description)
+		}
+	}
+	if s.authenticates() && !op.Public {
+		add("401", "Credentials are missing or invalid")
+		if s.Policy != nil {
+			add("403", "The caller lacks the required permission")
+		}
+	}
+	if s.Limiter != nil {
+		add("429", "Too many requests; see Retry-After")
+	}
+	if s.Config.OpenAPI.ValidateRequests {
+		if len(op.Parameters) > 0 || op.RequestBody != nil {
+			add("400", "The request does not match this document")
+		}
+		if op.RequestBody != nil && !op.StreamedBody {
+			add("413", "The body is larger than the server reads")
+			add("415", "The body's content type is not supported")
+		}
+	}
+}
+
+// serverOperations documents the routes the server registers itself.
+func serverOperations() map[string]*openapi.Operation {
+	status := &openapi.Schema{
+		Type:       "object",
+		Properties: map[string]*openapi.Schema{"status": openapi.String()},
+		Required:   []string{"status"},
+	}
+	readiness := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"status": openapi.String(),
+			"checks": {Type: "object", AdditionalProperties: &openapi.Schema{
+				Type: "object",
+				Properties: map[string]*openapi.Schema{
+					"status": openapi.String(),
+					"error":  openapi.String(),
+				},
+			}},
+		},
+	}
+	json := func(schema *openapi.Schema) map[string]*openapi.MediaType {
+		return map[string]*openapi.MediaType{"application/json": {Schema: schema}}
+	}
+	return map[string]*openapi.Operation{
+		"GET /healthz": {
+			OperationID: "liveness",
+			Summary:     "Report that the process is alive",
+			Tags:        []string{"operations"},
+			Responses:   map[string]*openapi.Response{"200": {Description: "Alive", Content: json(status)}},
+			Public:      true,
+		},
+		"GET /readyz": {
+			OperationID: "readiness",
+			Summary:     "Report whether the server accepts traffic",
+			Tags:        []string{"operations"},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "Ready", Content: json(readiness)},
+				"503": {Description: "Draining or a dependency is failing", Content: json(readiness)},
+			},
+			Public: true,
+		},
+		"GET /version": {
+			OperationID: "version",
+			Summary:     "Report build information",
+			Tags:        []string{"operations"},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "Build information", Content: json(openapi.Of[health.BuildInfo]())},
+			},
+			Public: true,
+		},
+		"GET /openapi.json": {
+			OperationID: "openapiJSON",
+			Summary:     "This document as JSON",
+			Tags:        []string{"documentation"},
+			Responses:   map[string]*openapi.Response{"200": {Description: "The OpenAPI document", Content: json(&openapi.Schema{Type: "object"})}},
+			Public:      true,
+		},
+		"GET /openapi.yaml": {
+			OperationID: "openapiYAML",
+			Summary:     "This document as YAML",
+			Tags:        []string{"documentation"},
+			Responses: map[string]*openapi.Response{"200": {Description: "The OpenAPI document", Content: map[string]*openapi.MediaType{
+				openapi.MIMEYAML: {Schema: &openapi.Schema{Type: "object"}},
+			}}},
+			Public: true,
+		},
+		"GET /docs": {
+			OperationID: "docs",
+			Summary:     "Interactive documentation",
+			Tags:        []string{"documentation"},
+			Responses: map[string]*openapi.Response{"200": {Description: "An HTML page", Content: map[string]*openapi.MediaType{
+				"text/html": {Schema: openapi.String()},
+			}}},
+			Public: true,
+		},
+	}
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/auth"
+	"github.com/faishalshidqi/gin-introductory-proj/src/authz"
+	"github.com/faishalshidqi/gin-introductory-proj/src/handlers"
+	"github.com/faishalshidqi/gin-introductory-proj/src/health"
+	"github.com/faishalshidqi/gin-introductory-proj/src/openapi"
+	"github.com/faishalshidqi/gin-introductory-proj/src/pagination"
+	"github.com/faishalshidqi/gin-introductory-proj/src/problem"
+	"github.com/faishalshidqi/gin-introductory-proj/src/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// reservedPaths are static routes that must keep winning over the /:name
+// wildcard. gin's tree always prefers a static segment, so they are safe as
+// long as they stay registered; New fails if one goes missing and would be
+// answered by IndexHandler instead.
+var reservedPaths = []string{"/healthz", "/readyz", "/version", "/openapi.json", "/openapi.yaml", "/docs"}
+
+func (s *Server) routes() {
+	router := s.Router
+	for _, method := range []string{"GET", "HEAD"} {
+		router.Handle(method, "/healthz", s.Health.LivenessHandler)
+		router.Handle(method, "/readyz", s.Health.ReadinessHandler)
+		router.Handle(method, "/version", health.VersionHandler)
+	}
+	if s.Metrics != nil {
+		router.GET("/metrics", s.Metrics.Handler())
+	}
+	router.GET("/openapi.json", s.served.JSONHandler)
+	router.GET("/openapi.yaml", s.served.YAMLHandler)
+	router.GET("/docs", openapi.DocsHandler)
+
+	// Everything below serves person data and sits behind authentication
+	// when it is enabled; the probes above and the greeting stay public.
+	authenticate := s.authenticate()
+	api := router.Group("/", s.limitByIP(), authenticate, s.limitByPrincipal())
+	if s.Config.Features.LegacyPerson {
+		api.GET(
+			"/person", s.require(authz.PersonsRead), handlers.PersonHandler,
+		)
+	}
+	reads := make(map[string]gin.HandlerFunc)
+	writes := make(map[string]gin.HandlerFunc)
+	if s.Config.Features.Persons {
+		paging := handlers.Paging{
+			Cursors:      pagination.NewSigner(s.Config.Paging.CursorSecret),
+			DefaultLimit: s.Config.Paging.DefaultLimit,
+			MaxLimit:     s.Config.Paging.MaxLimit,
+		}
+		persons := handlers.NewPersonsHandler(s.Store, paging)
+		search := handlers.NewSearchHandler(s.Search, paging)
+		group := api.Group("/persons")
+		group.GET("", s.require(authz.PersonsRead), persons.ListPersonsHandler)
+		group.GET("/search", s.require(authz.PersonsRead), search.SearchPersonsHandler)
+		group.POST("", s.require(authz.PersonsCreate), persons.NewPersonHandler)
+		group.GET("/:id", s.require(authz.PersonsRead), persons.GetPersonHandler)
+		// gin does not answer HEAD from GET routes; net/http drops the body.
+		group.HEAD("/:id", s.require(authz.PersonsRead), persons.GetPersonHandler)
+		group.PUT("/:id", s.requireOwner(authz.PersonsUpdate, persons), persons.UpdatePersonHandler)
+		group.PATCH("/:id", s.requireOwner(authz.PersonsUpdate, persons), persons.PatchPersonHandler)
+		group.DELETE("/:id", s.requireOwner(authz.PersonsDelete, persons), persons.DeletePersonHandler)
+
+		// gin reads ':' anywhere in a path as a parameter, and a wildcard in
+		// the middle of a segment such as /persons:action makes gin 1.10 panic
+		// while backtracking through sibling routes like /persons/search. The
+		// custom methods /persons:export and /persons:import are therefore
+		// served through /:name.
+		reads["export"] = chain(s.limitByIP(), authenticate, s.limitByPrincipal(), s.require(authz.PersonsRead), persons.ExportPersonsHandler)
+		writes["import"] = chain(s.limitByIP(), authenticate, s.limitByPrincipal(), s.require(authz.PersonsCreate), persons.ImportPersonsHandler)
+		s.customRoute(http.MethodGet, "/persons:export", persons.ExportPersonsHandler, reads["export"])
+		s.customRoute(http.MethodPost, "/persons:import", persons.ImportPersonsHandler, writes["import"])
+	}
+	if s.APIKeys != nil {
+		keys := handlers.NewAPIKeysHandler(s.APIKeys)
+		admin := api.Group("/admin/api-keys", s.require(authz.APIKeysManage))
+		admin.GET("", keys.ListAPIKeysHandler)
+		admin.POST("", keys.NewAPIKeyHandler)
+		admin.GET("/:id", keys.GetAPIKeyHandler)
+		admin.POST("/:id/rotate", keys.RotateAPIKeyHandler)
+		admin.DELETE("/:id", keys.RevokeAPIKeyHandler)
+	}
+
+	// The greeting shares /:name with the custom methods; POST has no
+	// route of its own there, so other names get gin's usual 404.
+	router.GET("/:name", customMethods("persons", reads, chain(s.limitByIP(), handlers.IndexHandler)))
+	if len(writes) > 0 {
+		router.POST("/:name", customMethods("persons", writes, notFound))
+	}
+}
+
+// authenticates reports whether the API requires credentials: a JWT, an
+// API key, or both.
+func (s *Server) authenticates() bool {
+	return s.Verifier != nil || s.APIKeys != nil
+}
+
+// authenticate requires credentials when JWT authentication or API keys are
+// enabled.
+func (s *Server) authenticate() gin.HandlerFunc {
+	if !s.authenticates() {
+		return passThrough
+	}
+	var keys auth.KeyAuthenticator
+	if s.APIKeys != nil {
+		keys = s.APIKeys
+	}
+	return auth.Authenticate(s.Verifier, keys)
+}
+
+// limitByIP limits every request per client IP when rate limiting is
+// enabled. It runs before authentication so failed attempts count too.
+func (s *Server) limitByIP() gin.HandlerFunc {
+	if s.Limiter == nil {
+		return passThrough
+	}
+	ip := s.Config.RateLimit.IP
+	return ratelimit.ByIP(s.Limiter, ratelimit.Limit{Rate: ip.Rate, Burst: ip.Burst})
+}
+
+// limitByPrincipal gives API keys and users their own buckets when rate
+// limiting is enabled. It runs after authentication.
+func (s *Server) limitByPrincipal() gin.HandlerFunc {
+	if s.Limiter == nil {
+		return passThrough
+	}
+	rl := s.Config.RateLimit
+	return ratelimit.ByPrincipal(s.Limiter, ratelimit.Limits{
+		APIKey: ratelimit.Limit{Rate: rl.APIKey.Rate, Burst: rl.APIKey.Burst},
+		User:   ratelimit.Limit{Rate: rl.User.Rate, Burst: rl.User.Burst},
+	})
+}
+
+// require enforces permission when an authorization policy is loaded and is
+// a pass-through otherwise.
+func (s *Server) require(permission string) gin.HandlerFunc {
+	if s.Policy == nil {
+		return passThrough
+	}
+	return s.Policy.Require(permission)
+}
+
+func (s *Server) requireOwner(permission string, persons *handlers.PersonsHandler) gin.HandlerFunc {
+	if s.Policy == nil {
+		return passThrough
+	}
+	return s.Policy.RequireOwner(permission, persons.PersonOwner)
+}
+
+func passThrough(c *gin.Context) {
+	c.Next()
+}
+
+// customMethods serves the custom methods of a collection, such as
+// /persons:export, from the /:name route, keyed by the method after the
+// colon. Names outside the collection go to fallback, so /foo:bar is
+// served as any other name.
+func customMethods(collection string, methods map[string]gin.HandlerFunc, fallback gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+		method, ok := strings.CutPrefix(name, collection+":")
+		if !ok {
+			fallback(c)
+			return
+		}
+		if h, ok := methods[method]; ok {
+			h(c)
+			return
+		}
+		problem.Write(c, problem.New(http.StatusNotFound, "no such method "+name))
+	}
+}
+
+// customRoute records a custom method for RouteTable, named after its
+// handler the way gin names the handlers of its own routes.
+func (s *Server) customRoute(method, path string, handler, chained gin.HandlerFunc) {
+	s.custom = append(s.custom, gin.RouteInfo{
+		Method:      method,
+		Path:        path,
+		Handler:     runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name(),
+		HandlerFunc: chained,
+	})
+}
+
+// notFound answers like gin does when no route matches.
+func notFound(c *gin.Context) {
+	c.Data(http.StatusNotFound, binding.MIMEPlain, []byte("404 page not found"))
+}
+
+// chain runs handlers in order until one aborts, like gin does for a
+// route. It must be the last handler of its route: a c.Next() inside the
+// chain then has nothing left to advance to.
+func chain(steps ...gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, h := range steps {
+			h(c)
+			if c.IsAborted() {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) checkReservedRoutes() error {
+	registered := make(map[string]bool)
+	for _, route := range s.Router.Routes() {
+		if route.Method == "GET" {
+			registered[route.Path] = true
+		}
+	}
+	paths := reservedPaths
+	if s.Metrics != nil {
+		paths = append(paths, "/metrics")
+	}
+	for _, path := range paths {
+		if !registered[path] {
+			return fmt.Errorf("server: reserved route GET %s is not registered", path)
+		}
+	}
+	return nil
+}
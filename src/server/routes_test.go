@@ -0,0 +1,172 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/config"
+	"github.com/faishalshidqi/gin-introductory-proj/src/openapi"
+)
+
+func TestRoutesAreDocumented(t *testing.T) {
+	dir := t.TempDir()
+	jwks := filepath.Join(dir, "jwks.json")
+	policy := filepath.Join(dir, "policy.yaml")
+	writeFile(t, jwks, `{"keys":[{"kty":"oct","kid":"k1","k":"MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY"}]}`)
+	writeFile(t, policy, "roles:\n  admin:\n    permissions: [api-keys:manage]\n")
+
+	tests := []struct {
+		name      string
+		configure func(*config.Config)
+	}{
+		{"defaults", func(*config.Config) {}},
+		{"features off", func(cfg *config.Config) {
+			cfg.Features = config.FeatureConfig{}
+		}},
+		{"api keys alone", func(cfg *config.Config) {
+			cfg.Auth.PolicyFile = policy
+			cfg.Auth.APIKeys = true
+		}},
+		{"everything on", func(cfg *config.Config) {
+			cfg.Auth.Enabled = true
+			cfg.Auth.JWKSFile = jwks
+			cfg.Auth.Issuer = "issuer"
+			cfg.Auth.Audience = "audience"
+			cfg.Auth.PolicyFile = policy
+			cfg.Auth.APIKeys = true
+			cfg.RateLimit.Enabled = true
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Gin.Mode = "test"
+			cfg.Log.Level = "error"
+			cfg.Store.DataDir = ""
+			tt.configure(cfg)
+			s, err := New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			served := servedRoutes(s.Spec)
+			for _, route := range s.Router.Routes() {
+				key := route.Method + " " + route.Path
+				if !served[key] {
+					t.Errorf("route %s is missing from the OpenAPI document", key)
+				}
+			}
+		})
+	}
+}
+
+func TestRouteTable(t *testing.T) {
+	dir := t.TempDir()
+	jwks := filepath.Join(dir, "jwks.json")
+	policy := filepath.Join(dir, "policy.yaml")
+	writeFile(t, jwks, `{"keys":[{"kty":"oct","kid":"k1","k":"MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY"}]}`)
+	writeFile(t, policy, "roles:\n  admin:\n    permissions: [api-keys:manage]\n")
+
+	cfg := config.Default()
+	cfg.Gin.Mode = "test"
+	cfg.Log.Level = "error"
+	cfg.Store.DataDir = ""
+	cfg.Auth.Enabled = true
+	cfg.Auth.JWKSFile = jwks
+	cfg.Auth.Issuer = "issuer"
+	cfg.Auth.Audience = "audience"
+	cfg.Auth.PolicyFile = policy
+	cfg.Auth.APIKeys = true
+	cfg.RateLimit.Enabled = true
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	want := make(map[string]bool)
+	for _, route := range s.Router.Routes() {
+		want[route.Method+" "+route.Path] = true
+	}
+	want["GET /persons:export"] = true
+	want["POST /persons:import"] = true
+
+	// None of the files the configuration names exist, which New would
+	// fail on; nor may the table replace the default logger.
+	missing := filepath.Join(dir, "missing")
+	cfg.Store.DataDir = missing
+	cfg.Auth.JWKSFile = missing
+	cfg.Auth.PolicyFile = missing
+	cfg.Auth.APIKeysFile = missing
+	cfg.Server.Mode = "tls"
+	cfg.Server.TLS.CertFile = missing
+	cfg.Server.TLS.KeyFile = missing
+	cfg.Tracing.Enabled = true
+	logger := slog.Default()
+	table, err := RouteTable(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slog.Default() != logger {
+		t.Error("RouteTable replaced the default logger")
+	}
+	got := make(map[string]bool)
+	for _, route := range table {
+		key := route.Method + " " + route.Path
+		got[key] = true
+		if route.Handler == "" || route.HandlerFunc == nil {
+			t.Errorf("route %s has no handler", key)
+		}
+	}
+	for key := range want {
+		if !got[key] {
+			t.Errorf("route %s is missing from the table", key)
+		}
+	}
+	for key := range got {
+		if !want[key] {
+			t.Errorf("route %s is not served", key)
+		}
+	}
+	if _, err := os.Stat(missing); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("RouteTable created %s", missing)
+	}
+}
+
+// servedRoutes lists the gin routes the document's operations are served
+// by, as "METHOD /gin/path".
+func servedRoutes(doc *openapi.Document) map[string]bool {
+	served := make(map[string]bool)
+	for path, item := range doc.Paths {
+		for method, op := range *item {
+			route := op.Route
+			if route == "" {
+				route = ginPath(path)
+			}
+			served[strings.ToUpper(method)+" "+route] = true
+		}
+	}
+	return served
+}
+
+// ginPath turns OpenAPI's /persons/{id} back into gin's /persons/:id.
+func ginPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if name, ok := strings.CutPrefix(seg, "{"); ok {
+			segments[i] = ":" + strings.TrimSuffix(name, "}")
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
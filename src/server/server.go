@@ -0,0 +1,330 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/apikeys"
+	"github.com/faishalshidqi/gin-introductory-proj/src/auth"
+	"github.com/faishalshidqi/gin-introductory-proj/src/authz"
+	"github.com/faishalshidqi/gin-introductory-proj/src/config"
+	"github.com/faishalshidqi/gin-introductory-proj/src/health"
+	"github.com/faishalshidqi/gin-introductory-proj/src/logging"
+	"github.com/faishalshidqi/gin-introductory-proj/src/metrics"
+	"github.com/faishalshidqi/gin-introductory-proj/src/openapi"
+	"github.com/faishalshidqi/gin-introductory-proj/src/ratelimit"
+	"github.com/faishalshidqi/gin-introductory-proj/src/search"
+	"github.com/faishalshidqi/gin-introductory-proj/src/store"
+	"github.com/faishalshidqi/gin-introductory-proj/src/tracing"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// Server bundles the gin router, its backing store and the http.Server
+// configured from a config.Config.
+type Server struct {
+	Config *config.Config
+	Router *gin.Engine
+	HTTP   *http.Server
+	Store  store.PersonStore
+
+	Health   *health.Registry
+	Logger   *slog.Logger
+	Metrics  *metrics.Registry
+	Tracer   *tracing.Tracer
+	Verifier *auth.Verifier
+	Policy   *authz.Policy
+	APIKeys  *apikeys.Store
+	Limiter  ratelimit.Backend
+	Search   *search.Index
+	// Spec describes every route; it is served at /openapi.json.
+	Spec *openapi.Document
+
+	served    openapi.Served
+	validator openapi.Validator
+	closers   []io.Closer
+	// custom lists the custom methods routes serves through /:name, which
+	// gin's route table shows only as that wildcard.
+	custom gin.RoutesInfo
+}
+
+// ErrDrainTimeout is returned by Run when in-flight requests did not finish
+// within the configured shutdown timeout and connections were cut.
+var ErrDrainTimeout = errors.New("server: drain deadline exceeded")
+
+func New(cfg *config.Config) (*Server, error) {
+	gin.SetMode(cfg.Gin.Mode)
+
+	s := &Server{
+		Config: cfg,
+		Health: health.NewRegistry(),
+		Logger: logging.New(cfg.Log.Format, cfg.Log.Level, os.Stderr),
+	}
+	slog.SetDefault(s.Logger)
+	if cfg.Tracing.Enabled {
+		exporter := tracing.NewOTLPExporter(cfg.Tracing.Endpoint, cfg.Tracing.ServiceName)
+		s.Tracer = tracing.NewTracer(cfg.Tracing.ServiceName, cfg.Tracing.SampleRatio, exporter)
+		s.closers = append(s.closers, s.Tracer)
+	}
+	if err := s.openStore(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if cfg.Auth.Enabled {
+		keys, err := auth.LoadKeySet(cfg.Auth.JWKSFile, cfg.Auth.ReloadInterval)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.closers = append(s.closers, keys)
+		s.Verifier = auth.NewVerifier(keys, auth.VerifierOptions{
+			Issuer:    cfg.Auth.Issuer,
+			Audience:  cfg.Auth.Audience,
+			ClockSkew: cfg.Auth.ClockSkew,
+		})
+	}
+	if cfg.Auth.PolicyFile != "" {
+		policy, err := authz.LoadPolicy(cfg.Auth.PolicyFile)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.Policy = policy
+	}
+	if cfg.Auth.APIKeys {
+		if cfg.Auth.APIKeysFile == "" {
+			s.Logger.Warn("api keys are kept in memory only and will be lost on restart; set auth.api_keys_file to persist them")
+		}
+		keys, err := apikeys.NewStore(cfg.Auth.APIKeysFile, cfg.Auth.APIKeysFlushInterval)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.APIKeys = keys
+		s.closers = append(s.closers, keys)
+	}
+	if cfg.RateLimit.Enabled {
+		backend := ratelimit.NewMemoryBackend()
+		s.Limiter = backend
+		s.closers = append(s.closers, backend)
+	}
+	var tlsConfig *tls.Config
+	if cfg.Server.Mode == "tls" {
+		certs, err := loadCertificates(cfg.Server.TLS)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.closers = append(s.closers, certs)
+		tlsConfig = certs.tlsConfig()
+	}
+	s.Health.Register("store", health.CheckerFunc(func(ctx context.Context) error {
+		return store.Ping(ctx, s.Store)
+	}))
+	if err := s.buildRouter(); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	var handler http.Handler = s.Router
+	if cfg.Server.Mode == "h2c" {
+		// Clients with prior knowledge, or that ask to upgrade, get
+		// HTTP/2; everyone else stays on HTTP/1.1.
+		handler = h2c.NewHandler(s.Router, &http2.Server{IdleTimeout: cfg.Server.IdleTimeout})
+	}
+	s.HTTP = &http.Server{
+		Addr:              cfg.Server.Address,
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	return s, nil
+}
+
+// RouteTable lists the routes New registers for cfg, including the custom
+// methods served through /:name. It opens nothing: the store is in memory
+// and authentication, authorization and rate limiting are wired to empty
+// stand-ins, so no key, policy or certificate file is read, no exporter
+// is started, and slog's default logger and gin's mode are left alone.
+func RouteTable(cfg *config.Config) (gin.RoutesInfo, error) {
+	s := &Server{
+		Config: cfg,
+		Health: health.NewRegistry(),
+		Logger: logging.New(cfg.Log.Format, "error", io.Discard),
+		Store:  store.NewMemoryStore(),
+	}
+	defer s.Close()
+	if cfg.Features.Persons {
+		s.Search = search.NewIndex()
+	}
+	if cfg.Auth.Enabled {
+		s.Verifier = auth.NewVerifier(nil, auth.VerifierOptions{})
+	}
+	if cfg.Auth.PolicyFile != "" {
+		s.Policy = &authz.Policy{}
+	}
+	if cfg.Auth.APIKeys {
+		keys, err := apikeys.NewStore("", 0)
+		if err != nil {
+			return nil, err
+		}
+		s.APIKeys = keys
+	}
+	if cfg.RateLimit.Enabled {
+		backend := ratelimit.NewMemoryBackend()
+		s.Limiter = backend
+		s.closers = append(s.closers, backend)
+	}
+	if err := s.buildRouter(); err != nil {
+		return nil, err
+	}
+	return append(s.Router.Routes(), s.custom...), nil
+}
+
+// buildRouter registers the middleware and routes the configuration and
+// the resources already on s call for, and loads the OpenAPI document
+// describing them.
+func (s *Server) buildRouter() error {
+	cfg := s.Config
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.Gin.TrustedProxies); err != nil {
+		return err
+	}
+	// Lets handlers pass the *gin.Context wherever a context.Context is
+	// expected and still reach values stored on the request context.
+	router.ContextWithFallback = true
+	if s.Tracer != nil {
+		router.Use(tracing.Middleware(s.Tracer))
+	}
+	router.Use(logging.Middleware(s.Logger))
+	if cfg.Server.TLS.ClientCAFile != "" {
+		router.Use(auth.ClientCertificate())
+	}
+	if cfg.Features.Metrics {
+		s.Metrics = metrics.NewRegistry()
+		metrics.RegisterRuntime(s.Metrics)
+		router.Use(metrics.NewHTTPMetrics(s.Metrics).Middleware())
+	}
+	router.Use(gin.Recovery())
+	if cfg.OpenAPI.ValidateRequests || cfg.OpenAPI.ValidateResponses {
+		s.validator.Requests = cfg.OpenAPI.ValidateRequests
+		s.validator.Responses = cfg.OpenAPI.ValidateResponses
+		s.validator.MaxBodyBytes = int64(cfg.OpenAPI.MaxBodyBytes)
+		router.Use(s.validator.Middleware())
+	}
+	s.Router = router
+	s.routes()
+	if err := s.checkReservedRoutes(); err != nil {
+		return err
+	}
+	spec, err := s.document()
+	if err == nil {
+		err = s.served.Load(spec)
+	}
+	if err != nil {
+		return err
+	}
+	s.Spec = spec
+	s.validator.Load(spec)
+	return nil
+}
+
+func (s *Server) openStore() error {
+	if s.Config.Store.DataDir == "" {
+		s.Store = store.NewMemoryStore()
+	} else {
+		fileStore, err := store.OpenFileStore(s.Config.Store.DataDir, s.Config.Store.CompactThreshold)
+		if err != nil {
+			return err
+		}
+		s.Store = fileStore
+		s.closers = append(s.closers, fileStore)
+	}
+	if s.Tracer != nil {
+		s.Store = store.WithTracing(s.Store, s.Tracer)
+	}
+	if s.Config.Features.Persons {
+		s.Search = search.NewIndex()
+		indexed, err := search.WithIndex(context.Background(), s.Store, s.Search)
+		if err != nil {
+			return err
+		}
+		s.Store = indexed
+	}
+	return nil
+}
+
+// Ready reports whether the server is accepting traffic; it turns false as
+// soon as draining begins.
+func (s *Server) Ready() bool {
+	return s.Health.Ready()
+}
+
+// Run serves until ctx is cancelled, then fails readiness, waits out the
+// drain delay and gracefully shuts down within the shutdown timeout.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.HTTP.Addr)
+	if err != nil {
+		return err
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if s.HTTP.TLSConfig != nil {
+			// The certificate comes from TLSConfig, so no files are named.
+			serveErr <- s.HTTP.ServeTLS(ln, "", "")
+			return
+		}
+		serveErr <- s.HTTP.Serve(ln)
+	}()
+	s.Health.SetReady(true)
+
+	select {
+	case err := <-serveErr:
+		s.Health.SetReady(false)
+		return err
+	case <-ctx.Done():
+	}
+
+	s.Health.SetReady(false)
+	s.Logger.Info("draining, readiness now failing", "shutdown_timeout", s.Config.Server.ShutdownTimeout.String())
+	if delay := s.Config.Server.DrainDelay; delay > 0 {
+		time.Sleep(delay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.Config.Server.ShutdownTimeout)
+	defer cancel()
+	if err := s.HTTP.Shutdown(shutdownCtx); err != nil {
+		s.HTTP.Close()
+		if errors.Is(err, context.DeadlineExceeded) {
+			return ErrDrainTimeout
+		}
+		return err
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Close releases the resources opened by New.
+func (s *Server) Close() error {
+	var first error
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		if err := s.closers[i].Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/config"
+)
+
+// certificates holds the serving certificate and the client CAs of the
+// tls mode and, like the JWKS in package auth, polls their files and
+// reloads them when they change. A reload that fails keeps the previous
+// files so a half-written rotation never takes the listener down.
+type certificates struct {
+	cfg        config.TLSConfig
+	clientAuth tls.ClientAuthType
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+
+	stop chan struct{}
+	once sync.Once
+}
+
+func loadCertificates(cfg config.TLSConfig) (*certificates, error) {
+	c := &certificates{cfg: cfg, stop: make(chan struct{})}
+	if cfg.ClientCAFile != "" {
+		c.clientAuth = tls.RequireAndVerifyClientCert
+		if cfg.ClientAuth == "optional" {
+			c.clientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+	if cfg.ReloadInterval > 0 {
+		go c.watch(cfg.ReloadInterval)
+	}
+	return c, nil
+}
+
+func (c *certificates) files() []string {
+	files := []string{c.cfg.CertFile, c.cfg.KeyFile}
+	if c.cfg.ClientCAFile != "" {
+		files = append(files, c.cfg.ClientCAFile)
+	}
+	return files
+}
+
+func (c *certificates) reload() error {
+	modTimes := make(map[string]time.Time)
+	for _, path := range c.files() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("server: tls: %w", err)
+		}
+		modTimes[path] = info.ModTime()
+	}
+	cert, err := tls.LoadX509KeyPair(c.cfg.CertFile, c.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("server: tls: %w", err)
+	}
+	var pool *x509.CertPool
+	if c.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(c.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("server: tls: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("server: tls: %s contains no PEM certificates", c.cfg.ClientCAFile)
+		}
+	}
+	c.mu.Lock()
+	c.cert, c.clientCAs, c.modTimes = &cert, pool, modTimes
+	c.mu.Unlock()
+	return nil
+}
+
+// changed reports whether any file's modification time differs from the
+// loaded one.
+func (c *certificates) changed() (bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, path := range c.files() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+		if !info.ModTime().Equal(c.modTimes[path]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *certificates) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			changed, err := c.changed()
+			if err != nil {
+				slog.Warn("server: stat tls files", "error", err)
+				continue
+			}
+			if !changed {
+				continue
+			}
+			if err := c.reload(); err != nil {
+				slog.Warn("server: reload tls files, keeping previous ones", "error", err)
+				continue
+			}
+			slog.Info("server: reloaded tls files", "cert_file", c.cfg.CertFile)
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// tlsConfig serves HTTP/2 and HTTP/1.1 with whatever certificate and
+// client CAs are loaded when each handshake starts.
+func (c *certificates) tlsConfig() *tls.Config {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	base.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return c.cert, nil
+	}
+	if c.clientAuth == tls.NoClientCert {
+		return base
+	}
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		if c.clientCAs == nil {
+			return nil, errors.New("server: tls: no client CAs loaded")
+		}
+		cfg := base.Clone()
+		cfg.GetConfigForClient = nil
+		cfg.ClientAuth = c.clientAuth
+		cfg.ClientCAs = c.clientCAs
+		return cfg, nil
+	}
+	return base
+}
+
+func (c *certificates) Close() error {
+	c.once.Do(func() { close(c.stop) })
+	return nil
+}
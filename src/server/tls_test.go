@@ -0,0 +1,353 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/auth"
+	"github.com/faishalshidqi/gin-introductory-proj/src/config"
+	"github.com/gin-gonic/gin"
+)
+
+// testCA issues the certificates of a test.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue signs tmpl, filling in its validity and key, and returns the
+// certificate and key as PEM.
+func (ca *testCA) issue(t *testing.T, tmpl *x509.Certificate) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+func (ca *testCA) serverCert(t *testing.T, serial int64) (certPEM, keyPEM []byte) {
+	return ca.issue(t, &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+}
+
+// clientCert returns a client certificate for cn and uris as a
+// tls.Certificate.
+func (ca *testCA) clientCert(t *testing.T, cn string, uris ...string) tls.Certificate {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: cn},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, raw := range uris {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl.URIs = append(tmpl.URIs, u)
+	}
+	cert, err := tls.X509KeyPair(ca.issue(t, tmpl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// replaceFile writes data to path and moves its modification time
+// forward, so the reload loop sees a change even on coarse clocks.
+func replaceFile(t *testing.T, path string, data []byte, age time.Duration) {
+	t.Helper()
+	writeFile(t, path, string(data))
+	mtime := time.Now().Add(age)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tlsServer serves GET /whoami, which answers with the request's
+// principal, in the tls mode configured by tlsCfg.
+func tlsServer(t *testing.T, tlsCfg config.TLSConfig) *httptest.Server {
+	t.Helper()
+	certs, err := loadCertificates(tlsCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { certs.Close() })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(auth.ClientCertificate())
+	router.GET("/whoami", func(c *gin.Context) {
+		p := auth.PrincipalFromContext(c)
+		if p == nil {
+			c.String(http.StatusOK, "anonymous")
+			return
+		}
+		c.String(http.StatusOK, p.Method+" "+p.Subject+" "+p.Issuer)
+	})
+	srv := httptest.NewUnstartedServer(router)
+	srv.TLS = certs.tlsConfig()
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// whoami asks srv who the client is over a new connection, trusting roots
+// and presenting clientCert, if any, whenever the server asks for one.
+func whoami(srv *httptest.Server, roots *x509.CertPool, clientCert *tls.Certificate) (body string, serverCert *x509.Certificate, err error) {
+	tlsCfg := &tls.Config{
+		RootCAs: roots,
+		// Without SNI the handshake would fall back to httptest's own
+		// certificate.
+		ServerName: "localhost",
+	}
+	if clientCert != nil {
+		// Unlike Certificates, this presents the certificate even when
+		// the server's CAs did not issue it, so the server must refuse it.
+		tlsCfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return clientCert, nil
+		}
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg, DisableKeepAlives: true}}
+	resp, err := client.Get(srv.URL + "/whoami")
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(b), resp.TLS.PeerCertificates[0], nil
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	serverCA := newTestCA(t, "server ca")
+	clientCA := newTestCA(t, "client ca")
+	otherCA := newTestCA(t, "other ca")
+	certPEM, keyPEM := serverCA.serverCert(t, 10)
+	certFile, keyFile, caFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem")
+	writeFile(t, certFile, string(certPEM))
+	writeFile(t, keyFile, string(keyPEM))
+	writeFile(t, caFile, string(clientCA.pem))
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCA.cert)
+
+	spiffe := clientCA.clientCert(t, "worker", "spiffe://example.org/worker", "spiffe://example.org/other")
+	cnOnly := clientCA.clientCert(t, "reporting")
+	untrusted := otherCA.clientCert(t, "intruder")
+
+	tests := []struct {
+		name       string
+		clientAuth string
+		clientCA   bool
+		cert       *tls.Certificate
+		// want is the /whoami answer, or empty when the handshake must
+		// fail.
+		want string
+	}{
+		{name: "no client ca", want: "anonymous"},
+		{name: "no client ca ignores a certificate", cert: &spiffe, want: "anonymous"},
+		{name: "require: uri san", clientAuth: "require", clientCA: true, cert: &spiffe, want: "mtls spiffe://example.org/worker client ca"},
+		{name: "require: common name", clientAuth: "require", clientCA: true, cert: &cnOnly, want: "mtls reporting client ca"},
+		{name: "require: no certificate", clientAuth: "require", clientCA: true},
+		{name: "require: untrusted certificate", clientAuth: "require", clientCA: true, cert: &untrusted},
+		{name: "require by default", clientCA: true},
+		{name: "optional: uri san", clientAuth: "optional", clientCA: true, cert: &spiffe, want: "mtls spiffe://example.org/worker client ca"},
+		{name: "optional: no certificate", clientAuth: "optional", clientCA: true, want: "anonymous"},
+		{name: "optional: untrusted certificate", clientAuth: "optional", clientCA: true, cert: &untrusted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientAuth: tt.clientAuth}
+			if tt.clientCA {
+				cfg.ClientCAFile = caFile
+			}
+			srv := tlsServer(t, cfg)
+			got, _, err := whoami(srv, roots, tt.cert)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("request succeeded as %q, want a failed handshake", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("whoami = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCertificateReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "ca")
+	rotatedCA := newTestCA(t, "rotated ca")
+	certFile, keyFile, caFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem")
+	certPEM, keyPEM := ca.serverCert(t, 1)
+	writeFile(t, certFile, string(certPEM))
+	writeFile(t, keyFile, string(keyPEM))
+	writeFile(t, caFile, string(ca.pem))
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := ca.clientCert(t, "client")
+	rotatedClient := rotatedCA.clientCert(t, "rotated client")
+
+	srv := tlsServer(t, config.TLSConfig{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ClientCAFile:   caFile,
+		ClientAuth:     "require",
+		ReloadInterval: 10 * time.Millisecond,
+	})
+	serial := func(clientCert *tls.Certificate) (int64, error) {
+		_, cert, err := whoami(srv, roots, clientCert)
+		if err != nil {
+			return 0, err
+		}
+		return cert.SerialNumber.Int64(), nil
+	}
+	// eventually retries check until it passes or the reload has had far
+	// longer than its interval to happen.
+	eventually := func(what string, check func() error) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			err := check()
+			if err == nil {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s: %v", what, err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	if got, err := serial(&client); err != nil || got != 1 {
+		t.Fatalf("serial = %d, %v; want 1", got, err)
+	}
+	if _, err := serial(&rotatedClient); err == nil {
+		t.Fatal("a client of the rotated ca was accepted before the rotation")
+	}
+
+	// A new serving certificate is picked up by the next handshake.
+	certPEM, keyPEM = ca.serverCert(t, 2)
+	replaceFile(t, keyFile, keyPEM, time.Minute)
+	replaceFile(t, certFile, certPEM, time.Minute)
+	eventually("serving certificate reload", func() error {
+		got, err := serial(&client)
+		if err == nil && got != 2 {
+			err = fmt.Errorf("still serving serial %d", got)
+		}
+		return err
+	})
+
+	// So are new client CAs.
+	replaceFile(t, caFile, rotatedCA.pem, 2*time.Minute)
+	eventually("client ca reload", func() error {
+		_, err := serial(&rotatedClient)
+		return err
+	})
+	if _, err := serial(&client); err == nil {
+		t.Error("a client of the replaced ca is still accepted")
+	}
+
+	// A broken rotation keeps the files loaded before it.
+	replaceFile(t, certFile, []byte("not a certificate"), 3*time.Minute)
+	time.Sleep(100 * time.Millisecond)
+	if got, err := serial(&rotatedClient); err != nil || got != 2 {
+		t.Errorf("after a broken rotation: serial = %d, %v; want the previous certificate", got, err)
+	}
+}
+
+func TestLoadCertificatesErrors(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "ca")
+	certPEM, keyPEM := ca.serverCert(t, 1)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeFile(t, certFile, string(certPEM))
+	writeFile(t, keyFile, string(keyPEM))
+	notPEM := filepath.Join(dir, "ca.txt")
+	writeFile(t, notPEM, "no certificates here")
+
+	tests := []struct {
+		name string
+		cfg  config.TLSConfig
+	}{
+		{"missing certificate", config.TLSConfig{CertFile: filepath.Join(dir, "missing.pem"), KeyFile: keyFile}},
+		{"key is not a key", config.TLSConfig{CertFile: certFile, KeyFile: certFile}},
+		{"missing client ca", config.TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: filepath.Join(dir, "missing.pem")}},
+		{"client ca without certificates", config.TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: notPEM}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if certs, err := loadCertificates(tt.cfg); err == nil {
+				certs.Close()
+				t.Error("loadCertificates succeeded, want an error")
+			}
+		})
+	}
+}
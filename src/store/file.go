@@ -0,0 +1,452 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+)
+
+const (
+	walFile      = "persons.wal"
+	snapshotFile = "persons.snapshot"
+
+	opPut    = "put"
+	opDelete = "delete"
+
+	recordHeaderSize = 8
+	maxRecordSize    = 1 << 20
+
+	DefaultCompactThreshold = 1000
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// walRecord is one committed mutation. Records are framed on disk as a
+// 4-byte big-endian payload length, a 4-byte CRC-32C of the payload, then the
+// JSON payload itself, so a torn tail is detectable on replay.
+type walRecord struct {
+	Seq    uint64         `json:"seq"`
+	Op     string         `json:"op"`
+	ID     string         `json:"id"`
+	Person *models.Person `json:"person,omitempty"`
+}
+
+type snapshot struct {
+	Seq     uint64          `json:"seq"`
+	Persons []models.Person `json:"persons"`
+}
+
+// FileStore is a durable PersonStore. Every mutation is appended to a
+// write-ahead log and fsynced before it becomes visible; the log is folded
+// into an atomically replaced snapshot once it grows past the compaction
+// threshold.
+type FileStore struct {
+	mu        sync.RWMutex
+	dir       string
+	wal       *os.File
+	walSize   int64
+	seq       uint64
+	records   int
+	threshold int
+	persons   map[string]models.Person
+	order     order
+}
+
+// OpenFileStore loads the snapshot and replays the log found in dir,
+// creating both if missing. A partially written record at the end of the
+// log (e.g. after kill -9) is discarded and truncated away; a damaged
+// record with more of the log after it is corruption and fails the open.
+func OpenFileStore(dir string, compactThreshold int) (*FileStore, error) {
+	if compactThreshold <= 0 {
+		compactThreshold = DefaultCompactThreshold
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &FileStore{
+		dir:       dir,
+		threshold: compactThreshold,
+		persons:   make(map[string]models.Person),
+	}
+	if err := s.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStore) loadSnapshot() error {
+	data, err := os.ReadFile(filepath.Join(s.dir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("store: corrupt snapshot: %w", err)
+	}
+	s.seq = snap.Seq
+	for _, p := range snap.Persons {
+		s.persons[p.ID] = withVersion(p)
+		s.order.insert(p)
+	}
+	return nil
+}
+
+func (s *FileStore) replay() error {
+	path := filepath.Join(s.dir, walFile)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	size := info.Size()
+	r := bufio.NewReader(f)
+	var good int64
+	for {
+		rec, n, err := readRecord(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			// Only the last write can have been interrupted: a record is a
+			// torn tail if it was cut short or claims to reach the end of
+			// the log. A damaged record with more log after it is
+			// corruption, and truncating would drop committed writes.
+			torn := errors.Is(err, errTornRecord) || errors.Is(err, errDamagedRecord) && good+n >= size
+			if !torn {
+				f.Close()
+				return fmt.Errorf("store: %s: record at offset %d, with %d more bytes after it: %w", path, good, size-good-n, err)
+			}
+			slog.Warn("store: discarding torn record at the end of the log", "path", path, "offset", good, "bytes", size-good)
+			if err := f.Truncate(good); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Sync(); err != nil {
+				f.Close()
+				return err
+			}
+			break
+		}
+		good += n
+		s.records++
+		if rec.Seq <= s.seq {
+			// Already folded into the snapshot before the log was reset.
+			continue
+		}
+		if err := s.apply(rec); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	s.wal = f
+	s.walSize = good
+	return nil
+}
+
+// readRecord reads one framed record and its length on disk. For a
+// damaged record the length is the one its header claims.
+func readRecord(r io.Reader) (walRecord, int64, error) {
+	var rec walRecord
+	header := make([]byte, recordHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return rec, 0, errTornRecord
+		}
+		return rec, 0, err
+	}
+	size := binary.BigEndian.Uint32(header[:4])
+	n := int64(recordHeaderSize) + int64(size)
+	if size > maxRecordSize {
+		return rec, n, fmt.Errorf("%w: length %d exceeds the maximum", errDamagedRecord, size)
+	}
+	sum := binary.BigEndian.Uint32(header[4:])
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return rec, n, errTornRecord
+	}
+	if crc32.Checksum(payload, crcTable) != sum {
+		return rec, n, fmt.Errorf("%w: checksum mismatch", errDamagedRecord)
+	}
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		// The checksum held, so these are the bytes that were written.
+		return rec, n, fmt.Errorf("store: corrupt wal record: %w", err)
+	}
+	return rec, n, nil
+}
+
+var (
+	// errTornRecord is a record cut short by the end of the log.
+	errTornRecord = errors.New("store: torn wal record")
+	// errDamagedRecord is a record whose bytes are not those written.
+	errDamagedRecord = errors.New("store: damaged wal record")
+)
+
+// apply folds rec into the in-memory view. Records that passed their
+// checksum but make no sense are corruption, not torn writes, so replay
+// stops instead of guessing.
+func (s *FileStore) apply(rec walRecord) error {
+	switch rec.Op {
+	case opPut:
+		if rec.Person == nil {
+			return fmt.Errorf("store: corrupt wal record %d: put without a person", rec.Seq)
+		}
+		old, existed := s.persons[rec.ID]
+		s.persons[rec.ID] = withVersion(*rec.Person)
+		s.order.replace(old, existed, *rec.Person)
+	case opDelete:
+		if old, ok := s.persons[rec.ID]; ok {
+			delete(s.persons, rec.ID)
+			s.order.remove(old)
+		}
+	default:
+		return fmt.Errorf("store: corrupt wal record %d: unknown op %q", rec.Seq, rec.Op)
+	}
+	s.seq = rec.Seq
+	return nil
+}
+
+// withVersion gives records written before versioning existed version 1.
+func withVersion(p models.Person) models.Person {
+	if p.Version == 0 {
+		p.Version = 1
+	}
+	return p
+}
+
+// commit appends rec to the log, fsyncs it and only then applies it to the
+// in-memory view. Callers must hold s.mu.
+func (s *FileStore) commit(rec walRecord) error {
+	rec.Seq = s.seq + 1
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(buf[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.Checksum(payload, crcTable))
+	copy(buf[recordHeaderSize:], payload)
+
+	if _, err := s.wal.Write(buf); err != nil {
+		s.rollback()
+		return err
+	}
+	if err := s.wal.Sync(); err != nil {
+		s.rollback()
+		return err
+	}
+	s.walSize += int64(len(buf))
+	if err := s.apply(rec); err != nil {
+		return err
+	}
+	s.records++
+	if s.records >= s.threshold {
+		// The record is already durable; a failed compaction is retried on
+		// the next commit rather than failing this one.
+		if err := s.compact(); err != nil {
+			slog.Error("store: compaction failed", "error", err)
+		}
+	}
+	return nil
+}
+
+// rollback cuts off a partially appended record so later commits are not
+// written behind garbage that replay would stop at.
+func (s *FileStore) rollback() {
+	if err := s.wal.Truncate(s.walSize); err != nil {
+		slog.Error("store: truncating wal after failed write", "error", err)
+	}
+}
+
+// Compact writes a fresh snapshot and resets the log.
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compact()
+}
+
+func (s *FileStore) compact() error {
+	data, err := json.Marshal(snapshot{Seq: s.seq, Persons: s.order.persons(s.persons)})
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(s.dir, snapshotFile), data); err != nil {
+		return err
+	}
+
+	// The snapshot now covers every logged record, so a crash from here on
+	// merely replays records that replay() skips by sequence number. The
+	// empty log is opened before it replaces the old one: swapping s.wal
+	// after the rename could fail and leave commits appending to an
+	// unlinked file.
+	path := filepath.Join(s.dir, walFile)
+	wal, err := os.OpenFile(path+".new", os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if err = wal.Sync(); err == nil {
+		err = os.Rename(path+".new", path)
+	}
+	if err != nil {
+		wal.Close()
+		os.Remove(path + ".new")
+		return err
+	}
+	s.wal.Close()
+	s.wal = wal
+	s.walSize = 0
+	s.records = 0
+	return syncDir(s.dir)
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// Close compacts the log and releases the underlying file.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.compact(); err != nil {
+		return err
+	}
+	return s.wal.Close()
+}
+
+func (s *FileStore) List(ctx context.Context) ([]models.Person, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.order.persons(s.persons), nil
+}
+
+func (s *FileStore) ListPage(ctx context.Context, after models.Person, limit int) ([]models.Person, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.order.page(s.persons, after, limit), nil
+}
+
+func (s *FileStore) Get(ctx context.Context, id string) (models.Person, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.persons[id]
+	if !ok {
+		return models.Person{}, ErrNotFound
+	}
+	return p, nil
+}
+
+func (s *FileStore) Create(ctx context.Context, person models.Person) (models.Person, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	person.ID = NewID()
+	person.CreatedAt = now
+	person.UpdatedAt = now
+	person.Version = 1
+	if err := s.commit(walRecord{Op: opPut, ID: person.ID, Person: &person}); err != nil {
+		return models.Person{}, err
+	}
+	return person, nil
+}
+
+func (s *FileStore) Update(ctx context.Context, person models.Person) (models.Person, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.persons[person.ID]
+	if !ok {
+		return models.Person{}, ErrNotFound
+	}
+	if err := checkVersion(current, person.Version); err != nil {
+		return models.Person{}, err
+	}
+	person.CreatedAt = current.CreatedAt
+	person.CreatedBy = current.CreatedBy
+	person.UpdatedAt = time.Now().UTC()
+	person.Version = current.Version + 1
+	if err := s.commit(walRecord{Op: opPut, ID: person.ID, Person: &person}); err != nil {
+		return models.Person{}, err
+	}
+	return person, nil
+}
+
+func (s *FileStore) Delete(ctx context.Context, id string, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.persons[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := checkVersion(current, version); err != nil {
+		return err
+	}
+	return s.commit(walRecord{Op: opDelete, ID: id})
+}
+
+// Ping verifies that the log is still open and the data directory writable.
+func (s *FileStore) Ping(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, err := s.wal.Stat(); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.dir, ".ping-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,275 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+)
+
+// crash drops the store the way kill -9 would: the log is closed without
+// the compaction Close performs.
+func crash(t *testing.T, s *FileStore) {
+	t.Helper()
+	if err := s.wal.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func appendWAL(t *testing.T, dir string, data []byte) {
+	t.Helper()
+	f, err := os.OpenFile(filepath.Join(dir, walFile), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func frame(t *testing.T, rec walRecord) []byte {
+	t.Helper()
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(buf[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.Checksum(payload, crcTable))
+	copy(buf[recordHeaderSize:], payload)
+	return buf
+}
+
+func TestFileStoreRecovery(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		// damage runs against the data directory after the crash.
+		damage  func(t *testing.T, dir string, s *FileStore)
+		wantErr bool
+	}{
+		{
+			name:   "intact log",
+			damage: func(*testing.T, string, *FileStore) {},
+		},
+		{
+			name: "torn header",
+			damage: func(t *testing.T, dir string, _ *FileStore) {
+				appendWAL(t, dir, []byte{0, 0, 0})
+			},
+		},
+		{
+			name: "torn payload",
+			damage: func(t *testing.T, dir string, s *FileStore) {
+				rec := frame(t, walRecord{Seq: s.seq + 1, Op: opDelete, ID: "x"})
+				appendWAL(t, dir, rec[:len(rec)-4])
+			},
+		},
+		{
+			name: "checksum mismatch",
+			damage: func(t *testing.T, dir string, s *FileStore) {
+				rec := frame(t, walRecord{Seq: s.seq + 1, Op: opDelete, ID: "x"})
+				rec[4] ^= 0xff
+				appendWAL(t, dir, rec)
+			},
+		},
+		{
+			name: "oversized length",
+			damage: func(t *testing.T, dir string, _ *FileStore) {
+				header := make([]byte, recordHeaderSize)
+				binary.BigEndian.PutUint32(header, maxRecordSize+1)
+				appendWAL(t, dir, header)
+			},
+		},
+		{
+			name: "checksum mismatch mid-log",
+			damage: func(t *testing.T, dir string, _ *FileStore) {
+				path := filepath.Join(dir, walFile)
+				log, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				log[recordHeaderSize] ^= 0xff
+				if err := os.WriteFile(path, log, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "oversized length mid-log",
+			damage: func(t *testing.T, dir string, s *FileStore) {
+				header := make([]byte, recordHeaderSize)
+				binary.BigEndian.PutUint32(header, maxRecordSize+1)
+				appendWAL(t, dir, header)
+				appendWAL(t, dir, make([]byte, maxRecordSize+1))
+				appendWAL(t, dir, frame(t, walRecord{Seq: s.seq + 1, Op: opDelete, ID: "x"}))
+			},
+			wantErr: true,
+		},
+		{
+			name: "undecodable record",
+			damage: func(t *testing.T, dir string, _ *FileStore) {
+				payload := []byte("not json")
+				rec := make([]byte, recordHeaderSize, recordHeaderSize+len(payload))
+				binary.BigEndian.PutUint32(rec, uint32(len(payload)))
+				binary.BigEndian.PutUint32(rec[4:], crc32.Checksum(payload, crcTable))
+				appendWAL(t, dir, append(rec, payload...))
+			},
+			wantErr: true,
+		},
+		{
+			name: "log not reset after snapshot",
+			damage: func(t *testing.T, dir string, s *FileStore) {
+				// A crash between writing the snapshot and resetting the log
+				// leaves records the snapshot already holds.
+				log, err := os.ReadFile(filepath.Join(dir, walFile))
+				if err != nil {
+					t.Fatal(err)
+				}
+				s.mu.Lock()
+				err = s.compact()
+				s.mu.Unlock()
+				if err != nil {
+					t.Fatal(err)
+				}
+				appendWAL(t, dir, log)
+			},
+		},
+		{
+			name: "put without a person",
+			damage: func(t *testing.T, dir string, s *FileStore) {
+				appendWAL(t, dir, frame(t, walRecord{Seq: s.seq + 1, Op: opPut, ID: "x"}))
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown op",
+			damage: func(t *testing.T, dir string, s *FileStore) {
+				appendWAL(t, dir, frame(t, walRecord{Seq: s.seq + 1, Op: "rename", ID: "x"}))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s, err := OpenFileStore(dir, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []models.Person
+			for _, name := range []string{"Ada", "Grace", "Alan"} {
+				p, err := s.Create(ctx, models.Person{FirstName: name, LastName: "Test"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				want = append(want, p)
+			}
+			updated := want[0]
+			updated.LastName = "Lovelace"
+			if want[0], err = s.Update(ctx, updated); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Delete(ctx, want[1].ID, 0); err != nil {
+				t.Fatal(err)
+			}
+			want = append(want[:1], want[2])
+
+			tt.damage(t, dir, s)
+			crash(t, s)
+			log, err := os.ReadFile(filepath.Join(dir, walFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s, err = OpenFileStore(dir, 100)
+			if tt.wantErr {
+				if err == nil {
+					s.Close()
+					t.Fatal("OpenFileStore succeeded on a corrupt log")
+				}
+				// A failed open must leave the log as it was for inspection.
+				after, err := os.ReadFile(filepath.Join(dir, walFile))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(after, log) {
+					t.Errorf("log changed from %d to %d bytes on a failed open", len(log), len(after))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertPersons(t, s, want)
+
+			// The damaged tail must be gone, or this record would sit behind
+			// it and be lost on the next replay.
+			p, err := s.Create(ctx, models.Person{FirstName: "Edsger", LastName: "Test"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want = append(want, p)
+			crash(t, s)
+			s, err = OpenFileStore(dir, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			assertPersons(t, s, want)
+		})
+	}
+}
+
+func assertPersons(t *testing.T, s *FileStore, want []models.Person) {
+	t.Helper()
+	got, err := s.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d persons, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.ID != w.ID || g.LastName != w.LastName || g.Version != w.Version || !g.UpdatedAt.Equal(w.UpdatedAt) {
+			t.Errorf("person %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestFileStoreCompactionKeepsLogging(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	// A threshold of 2 compacts on every second commit.
+	s, err := OpenFileStore(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []models.Person
+	for range 5 {
+		p, err := s.Create(ctx, models.Person{FirstName: "Ada", LastName: "Test"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, p)
+	}
+	crash(t, s)
+	if _, err := os.Stat(filepath.Join(dir, walFile+".new")); !os.IsNotExist(err) {
+		t.Errorf("temporary log left behind: %v", err)
+	}
+	s, err = OpenFileStore(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	assertPersons(t, s, want)
+}
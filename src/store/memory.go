@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+)
+
+type MemoryStore struct {
+	mu      sync.RWMutex
+	persons map[string]models.Person
+	order   order
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{persons: make(map[string]models.Person)}
+}
+
+func (s *MemoryStore) List(ctx context.Context) ([]models.Person, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.order.persons(s.persons), nil
+}
+
+func (s *MemoryStore) ListPage(ctx context.Context, after models.Person, limit int) ([]models.Person, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.order.page(s.persons, after, limit), nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (models.Person, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.persons[id]
+	if !ok {
+		return models.Person{}, ErrNotFound
+	}
+	return p, nil
+}
+
+func (s *MemoryStore) Create(ctx context.Context, person models.Person) (models.Person, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	person.ID = NewID()
+	person.CreatedAt = now
+	person.UpdatedAt = now
+	person.Version = 1
+	s.persons[person.ID] = person
+	s.order.insert(person)
+	return person, nil
+}
+
+func (s *MemoryStore) Update(ctx context.Context, person models.Person) (models.Person, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.persons[person.ID]
+	if !ok {
+		return models.Person{}, ErrNotFound
+	}
+	if err := checkVersion(current, person.Version); err != nil {
+		return models.Person{}, err
+	}
+	person.CreatedAt = current.CreatedAt
+	person.CreatedBy = current.CreatedBy
+	person.UpdatedAt = time.Now().UTC()
+	person.Version = current.Version + 1
+	s.persons[person.ID] = person
+	return person, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.persons[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := checkVersion(current, version); err != nil {
+		return err
+	}
+	delete(s.persons, id)
+	s.order.remove(current)
+	return nil
+}
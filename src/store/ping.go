@@ -0,0 +1,18 @@
+package store
+
+import "context"
+
+// Pinger is implemented by stores that can verify their backing resources
+// without touching any records.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping checks s if it supports it; stores without external resources are
+// always reachable.
+func Ping(ctx context.Context, s PersonStore) error {
+	if p, ok := s.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+)
+
+var (
+	ErrNotFound = errors.New("person not found")
+	// ErrConflict means the record changed since the version the caller
+	// based its write on.
+	ErrConflict = errors.New("person was modified concurrently")
+)
+
+// PersonStore is the persistence boundary for Person records. Implementations
+// own ID, timestamp and version assignment so handlers never have to.
+//
+// Update and Delete are conditional when given a non-zero version: they
+// fail with ErrConflict unless the stored record still has that version.
+type PersonStore interface {
+	List(ctx context.Context) ([]models.Person, error)
+	// ListPage returns up to limit persons that follow after in List
+	// order, so callers can walk a large store without holding it all.
+	// A zero after starts at the beginning.
+	ListPage(ctx context.Context, after models.Person, limit int) ([]models.Person, error)
+	Get(ctx context.Context, id string) (models.Person, error)
+	Create(ctx context.Context, person models.Person) (models.Person, error)
+	Update(ctx context.Context, person models.Person) (models.Person, error)
+	Delete(ctx context.Context, id string, version int64) error
+}
+
+func checkVersion(current models.Person, expected int64) error {
+	if expected != 0 && current.Version != expected {
+		return ErrConflict
+	}
+	return nil
+}
+
+// order holds the stores' records sorted in List order, by CreatedAt and
+// then ID, so a page is found by binary search rather than by sorting the
+// whole store on every call.
+type order []orderKey
+
+type orderKey struct {
+	createdAt time.Time
+	id        string
+}
+
+func keyOf(p models.Person) orderKey {
+	return orderKey{createdAt: p.CreatedAt, id: p.ID}
+}
+
+func compareKeys(a, b orderKey) int {
+	if c := a.createdAt.Compare(b.createdAt); c != 0 {
+		return c
+	}
+	return strings.Compare(a.id, b.id)
+}
+
+func (o *order) insert(p models.Person) {
+	k := keyOf(p)
+	i, found := slices.BinarySearchFunc(*o, k, compareKeys)
+	if !found {
+		*o = slices.Insert(*o, i, k)
+	}
+}
+
+func (o *order) remove(p models.Person) {
+	if i, found := slices.BinarySearchFunc(*o, keyOf(p), compareKeys); found {
+		*o = slices.Delete(*o, i, i+1)
+	}
+}
+
+// replace moves old, if the store held it, to where p sorts.
+func (o *order) replace(old models.Person, existed bool, p models.Person) {
+	if existed {
+		if compareKeys(keyOf(old), keyOf(p)) == 0 {
+			return
+		}
+		o.remove(old)
+	}
+	o.insert(p)
+}
+
+// persons looks up the records of keys in persons.
+func (o order) persons(persons map[string]models.Person) []models.Person {
+	out := make([]models.Person, len(o))
+	for i, k := range o {
+		out[i] = persons[k.id]
+	}
+	return out
+}
+
+// page returns up to limit of persons, in List order, that follow after.
+func (o order) page(persons map[string]models.Person, after models.Person, limit int) []models.Person {
+	i, found := slices.BinarySearchFunc(o, keyOf(after), compareKeys)
+	if found {
+		i++
+	}
+	keys := o[i:]
+	if len(keys) > limit {
+		keys = keys[:max(limit, 0)]
+	}
+	return keys.persons(persons)
+}
+
+func NewID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+)
+
+func TestListPage(t *testing.T) {
+	ctx := context.Background()
+	file, err := OpenFileStore(t.TempDir(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	stores := []struct {
+		name  string
+		store PersonStore
+	}{
+		{"memory", NewMemoryStore()},
+		{"file", file},
+	}
+	for _, st := range stores {
+		t.Run(st.name, func(t *testing.T) {
+			s := st.store
+			var ids []string
+			for i := range 50 {
+				p, err := s.Create(ctx, models.Person{FirstName: "Ada", LastName: "Test"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, p.ID)
+				if i%7 == 3 {
+					if _, err := s.Update(ctx, p); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			for _, id := range ids[10:20] {
+				if err := s.Delete(ctx, id, 0); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := s.List(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 1; i < len(want); i++ {
+				if follows := want[i].CreatedAt.After(want[i-1].CreatedAt) ||
+					want[i].CreatedAt.Equal(want[i-1].CreatedAt) && want[i].ID > want[i-1].ID; !follows {
+					t.Fatalf("List is out of order at %d", i)
+				}
+			}
+			for _, limit := range []int{1, 7, 40, 100} {
+				var got []models.Person
+				var after models.Person
+				for {
+					page, err := s.ListPage(ctx, after, limit)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(page) > limit {
+						t.Fatalf("page of %d exceeds limit %d", len(page), limit)
+					}
+					if len(page) == 0 {
+						break
+					}
+					got = append(got, page...)
+					after = page[len(page)-1]
+				}
+				if len(got) != len(want) {
+					t.Fatalf("limit %d: walked %d persons, want %d", limit, len(got), len(want))
+				}
+				for i := range want {
+					if got[i].ID != want[i].ID || got[i].Version != want[i].Version {
+						t.Fatalf("limit %d: person %d = %s, want %s", limit, i, got[i].ID, want[i].ID)
+					}
+				}
+			}
+		})
+	}
+}
+
+// A cursor whose person was deleted still resumes at the next person.
+func TestListPageAfterDeleted(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	for range 5 {
+		if _, err := s.Create(ctx, models.Person{FirstName: "Ada", LastName: "Test"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	all, _ := s.List(ctx)
+	if err := s.Delete(ctx, all[2].ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	page, err := s.ListPage(ctx, all[2], 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[0].ID != all[3].ID || page[1].ID != all[4].ID {
+		t.Errorf("page after a deleted person = %v, want %s and %s", page, all[3].ID, all[4].ID)
+	}
+	if page, _ := s.ListPage(ctx, models.Person{CreatedAt: time.Now().Add(time.Hour)}, 10); len(page) != 0 {
+		t.Errorf("page after the last person = %v, want none", page)
+	}
+}
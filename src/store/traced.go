@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+	"github.com/faishalshidqi/gin-introductory-proj/src/tracing"
+)
+
+// TracedStore records an internal span around every operation of the
+// wrapped store.
+type TracedStore struct {
+	inner  PersonStore
+	tracer *tracing.Tracer
+}
+
+func WithTracing(inner PersonStore, tracer *tracing.Tracer) *TracedStore {
+	return &TracedStore{inner: inner, tracer: tracer}
+}
+
+func (s *TracedStore) start(ctx context.Context, op string) (context.Context, *tracing.Span) {
+	ctx, span := s.tracer.Start(ctx, "PersonStore."+op, tracing.KindInternal, tracing.SpanContext{})
+	span.SetAttribute("store.operation", op)
+	return ctx, span
+}
+
+func (s *TracedStore) List(ctx context.Context) ([]models.Person, error) {
+	ctx, span := s.start(ctx, "List")
+	defer span.End()
+	persons, err := s.inner.List(ctx)
+	span.RecordError(err)
+	span.SetAttribute("store.result_count", len(persons))
+	return persons, err
+}
+
+func (s *TracedStore) ListPage(ctx context.Context, after models.Person, limit int) ([]models.Person, error) {
+	ctx, span := s.start(ctx, "ListPage")
+	defer span.End()
+	span.SetAttribute("store.limit", limit)
+	persons, err := s.inner.ListPage(ctx, after, limit)
+	span.RecordError(err)
+	span.SetAttribute("store.result_count", len(persons))
+	return persons, err
+}
+
+func (s *TracedStore) Get(ctx context.Context, id string) (models.Person, error) {
+	ctx, span := s.start(ctx, "Get")
+	defer span.End()
+	span.SetAttribute("person.id", id)
+	person, err := s.inner.Get(ctx, id)
+	span.RecordError(err)
+	return person, err
+}
+
+func (s *TracedStore) Create(ctx context.Context, person models.Person) (models.Person, error) {
+	ctx, span := s.start(ctx, "Create")
+	defer span.End()
+	person, err := s.inner.Create(ctx, person)
+	span.RecordError(err)
+	span.SetAttribute("person.id", person.ID)
+	return person, err
+}
+
+func (s *TracedStore) Update(ctx context.Context, person models.Person) (models.Person, error) {
+	ctx, span := s.start(ctx, "Update")
+	defer span.End()
+	span.SetAttribute("person.id", person.ID)
+	person, err := s.inner.Update(ctx, person)
+	span.RecordError(err)
+	return person, err
+}
+
+func (s *TracedStore) Delete(ctx context.Context, id string, version int64) error {
+	ctx, span := s.start(ctx, "Delete")
+	defer span.End()
+	span.SetAttribute("person.id", id)
+	err := s.inner.Delete(ctx, id, version)
+	span.RecordError(err)
+	return err
+}
+
+func (s *TracedStore) Ping(ctx context.Context) error {
+	return Ping(ctx, s.inner)
+}
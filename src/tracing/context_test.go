@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+	tests := []struct {
+		name        string
+		value       string
+		wantErr     bool
+		wantSampled bool
+	}{
+		{name: "sampled", value: "00-" + traceID + "-" + spanID + "-01", wantSampled: true},
+		{name: "not sampled", value: "00-" + traceID + "-" + spanID + "-00"},
+		{name: "other flags", value: "00-" + traceID + "-" + spanID + "-03", wantSampled: true},
+		{name: "surrounding space", value: " 00-" + traceID + "-" + spanID + "-01 ", wantSampled: true},
+		{name: "future version", value: "01-" + traceID + "-" + spanID + "-01-extra", wantSampled: true},
+		{name: "version 00 with extra field", value: "00-" + traceID + "-" + spanID + "-01-extra", wantErr: true},
+		{name: "version ff", value: "ff-" + traceID + "-" + spanID + "-01", wantErr: true},
+		{name: "uppercase", value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-" + spanID + "-01", wantErr: true},
+		{name: "short trace id", value: "00-" + traceID[:30] + "-" + spanID + "-01", wantErr: true},
+		{name: "long span id", value: "00-" + traceID + "-" + spanID + "00-01", wantErr: true},
+		{name: "zero trace id", value: "00-00000000000000000000000000000000-" + spanID + "-01", wantErr: true},
+		{name: "zero span id", value: "00-" + traceID + "-0000000000000000-01", wantErr: true},
+		{name: "bad flags", value: "00-" + traceID + "-" + spanID + "-0x", wantErr: true},
+		{name: "too few fields", value: "00-" + traceID + "-" + spanID, wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := ParseTraceparent(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseTraceparent(%q) = %+v, want an error", tt.value, sc)
+				}
+				if sc.IsValid() {
+					t.Errorf("invalid traceparent gave a valid context %+v", sc)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sc.TraceID.String() != traceID || sc.SpanID.String() != spanID {
+				t.Errorf("ids = %s, %s; want %s, %s", sc.TraceID, sc.SpanID, traceID, spanID)
+			}
+			if sc.Sampled != tt.wantSampled || !sc.Remote {
+				t.Errorf("sampled = %v, remote = %v; want %v, true", sc.Sampled, sc.Remote, tt.wantSampled)
+			}
+		})
+	}
+}
+
+func TestExtractInject(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	in := http.Header{}
+	in.Set(TraceparentHeader, traceparent)
+	in.Add(TracestateHeader, "a=1")
+	in.Add(TracestateHeader, "b=2")
+	sc := Extract(in)
+	if sc.TraceState != "a=1,b=2" {
+		t.Errorf("tracestate = %q, want the header lines joined", sc.TraceState)
+	}
+	out := http.Header{}
+	Inject(sc, out)
+	if got := out.Get(TraceparentHeader); got != traceparent {
+		t.Errorf("traceparent = %q, want %q", got, traceparent)
+	}
+	if got := out.Get(TracestateHeader); got != "a=1,b=2" {
+		t.Errorf("tracestate = %q, want a=1,b=2", got)
+	}
+
+	// Without a valid traceparent, tracestate means nothing and is dropped.
+	in.Set(TraceparentHeader, "garbage")
+	if sc := Extract(in); sc.IsValid() || sc.TraceState != "" {
+		t.Errorf("Extract = %+v, want an empty context", sc)
+	}
+	out = http.Header{}
+	Inject(SpanContext{}, out)
+	if len(out) != 0 {
+		t.Errorf("Inject of an invalid context wrote %v", out)
+	}
+}
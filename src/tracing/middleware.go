@@ -0,0 +1,37 @@
+package tracing
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware continues the caller's trace from traceparent/tracestate (or
+// starts a new one), wraps the request in a server span named after the
+// route template, and echoes the span's traceparent on the response.
+func Middleware(t *Tracer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		remote := Extract(c.Request.Header)
+		ctx, span := t.Start(c.Request.Context(), c.Request.Method+" "+route, KindServer, remote)
+		c.Request = c.Request.WithContext(ctx)
+		Inject(span.SpanContext(), c.Writer.Header())
+
+		span.SetAttribute("http.request.method", c.Request.Method)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("url.path", c.Request.URL.Path)
+		span.SetAttribute("client.address", c.ClientIP())
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttribute("http.response.status_code", status)
+		if status >= 500 {
+			span.SetStatus(StatusError, strconv.Itoa(status))
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,186 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/models"
+	"github.com/faishalshidqi/gin-introductory-proj/src/store"
+	"github.com/faishalshidqi/gin-introductory-proj/src/tracing"
+	"github.com/faishalshidqi/gin-introductory-proj/src/tracing/tracingtest"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+const (
+	callerTrace = "4bf92f3577b34da6a3ce929d0e0e4736"
+	callerSpan  = "00f067aa0ba902b7"
+)
+
+// newTracedRouter serves POST /persons/:name from a traced memory store,
+// exporting to collector, and GET /fail with a 500.
+func newTracedRouter(collector *tracingtest.Collector) (*gin.Engine, *tracing.Tracer) {
+	tracer := tracing.NewTracer("test", 1, tracing.NewOTLPExporter(collector.Endpoint(), "test"))
+	persons := store.WithTracing(store.NewMemoryStore(), tracer)
+	router := gin.New()
+	router.Use(tracing.Middleware(tracer))
+	router.POST("/persons/:name", func(c *gin.Context) {
+		p, err := persons.Create(c.Request.Context(), models.Person{FirstName: c.Param("name"), LastName: "Test"})
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		if _, err := persons.Get(c.Request.Context(), p.ID); err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+	router.GET("/fail", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+	return router, tracer
+}
+
+func TestMiddlewareExportsSpans(t *testing.T) {
+	collector := tracingtest.NewCollector()
+	defer collector.Close()
+	router, tracer := newTracedRouter(collector)
+
+	req := httptest.NewRequest(http.MethodPost, "/persons/ada", nil)
+	req.Header.Set(tracing.TraceparentHeader, "00-"+callerTrace+"-"+callerSpan+"-01")
+	req.Header.Set(tracing.TracestateHeader, "vendor=x")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := make(map[string]tracing.SpanData)
+	for _, s := range collector.Spans() {
+		spans[s.Name] = s
+	}
+	if len(spans) != 3 {
+		t.Fatalf("collector received %v, want the server span and two store spans", collector.Spans())
+	}
+	server, ok := spans["POST /persons/:name"]
+	if !ok {
+		t.Fatalf("no span named after the route in %v", collector.Spans())
+	}
+	if server.TraceID != callerTrace || server.ParentSpanID != callerSpan {
+		t.Errorf("server span continues %s/%s, want the caller's %s/%s", server.TraceID, server.ParentSpanID, callerTrace, callerSpan)
+	}
+	if server.Kind != int(tracing.KindServer) || server.TraceState != "vendor=x" {
+		t.Errorf("server span kind %d, tracestate %q; want %d, vendor=x", server.Kind, server.TraceState, tracing.KindServer)
+	}
+	wantAttrs := map[string]string{
+		"http.request.method":       "POST",
+		"http.route":                "/persons/:name",
+		"url.path":                  "/persons/ada",
+		"http.response.status_code": "201",
+	}
+	for key, want := range wantAttrs {
+		if got := attribute(server, key); got != want {
+			t.Errorf("server span %s = %q, want %q", key, got, want)
+		}
+	}
+	for _, name := range []string{"PersonStore.Create", "PersonStore.Get"} {
+		child, ok := spans[name]
+		if !ok {
+			t.Errorf("no %s span in %v", name, collector.Spans())
+			continue
+		}
+		if child.TraceID != callerTrace || child.ParentSpanID != server.SpanID {
+			t.Errorf("%s is under %s/%s, want the server span %s/%s", name, child.TraceID, child.ParentSpanID, callerTrace, server.SpanID)
+		}
+		if child.Kind != int(tracing.KindInternal) || attribute(child, "person.id") == "" {
+			t.Errorf("%s: kind %d, attributes %v", name, child.Kind, child.Attributes)
+		}
+	}
+
+	// The response carries the server span, so the caller can link to it.
+	want := "00-" + callerTrace + "-" + server.SpanID + "-01"
+	if got := rec.Header().Get(tracing.TraceparentHeader); got != want {
+		t.Errorf("response traceparent = %q, want %q", got, want)
+	}
+}
+
+func TestMiddlewareSampling(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		path        string
+		// wantSpans is what the collector receives; a server span with a
+		// 500 must carry an error status.
+		wantSpans int
+		wantError bool
+	}{
+		{name: "caller sampled", traceparent: "00-" + callerTrace + "-" + callerSpan + "-01", path: "/persons/ada", wantSpans: 3},
+		{name: "caller not sampled", traceparent: "00-" + callerTrace + "-" + callerSpan + "-00", path: "/persons/ada"},
+		{name: "new trace", path: "/persons/ada", wantSpans: 3},
+		{name: "malformed traceparent", traceparent: "00-zz", path: "/persons/ada", wantSpans: 3},
+		{name: "server error", path: "/fail", wantSpans: 1, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := tracingtest.NewCollector()
+			defer collector.Close()
+			router, tracer := newTracedRouter(collector)
+
+			method := http.MethodPost
+			if tt.path == "/fail" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tt.path, nil)
+			if tt.traceparent != "" {
+				req.Header.Set(tracing.TraceparentHeader, tt.traceparent)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if err := tracer.Shutdown(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			spans := collector.Spans()
+			if len(spans) != tt.wantSpans {
+				t.Fatalf("collector received %d spans, want %d", len(spans), tt.wantSpans)
+			}
+			sc, err := tracing.ParseTraceparent(rec.Header().Get(tracing.TraceparentHeader))
+			if err != nil {
+				t.Fatalf("response traceparent: %v", err)
+			}
+			if sc.Sampled != (tt.wantSpans > 0) {
+				t.Errorf("response sampled = %v, want %v", sc.Sampled, tt.wantSpans > 0)
+			}
+			for _, s := range spans {
+				if s.TraceID != sc.TraceID.String() {
+					t.Errorf("span %s in trace %s, want %s", s.Name, s.TraceID, sc.TraceID)
+				}
+				if s.Kind == int(tracing.KindServer) && (s.Status.Code == int(tracing.StatusError)) != tt.wantError {
+					t.Errorf("server span status = %+v, want error %v", s.Status, tt.wantError)
+				}
+			}
+		})
+	}
+}
+
+// attribute returns the value of key on s as a string.
+func attribute(s tracing.SpanData, key string) string {
+	for _, kv := range s.Attributes {
+		if kv.Key != key {
+			continue
+		}
+		switch v := kv.Value; {
+		case v.StringValue != nil:
+			return *v.StringValue
+		case v.IntValue != nil:
+			return *v.IntValue
+		}
+	}
+	return ""
+}
@@ -0,0 +1,196 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"sync"
+	"time"
+)
+
+type SpanKind int
+
+// Values match the OTLP SpanKind enum.
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Exporter receives ended, sampled spans.
+type Exporter interface {
+	Export(span *Span)
+	Shutdown(ctx context.Context) error
+}
+
+// Tracer starts spans. The zero value is unusable; a nil *Tracer is a valid
+// no-op tracer so call sites need not check whether tracing is enabled.
+type Tracer struct {
+	service  string
+	exporter Exporter
+	// threshold is the sampling ratio scaled to the uint64 range.
+	threshold uint64
+}
+
+// NewTracer samples root spans with probability ratio; child spans follow
+// their parent's decision.
+func NewTracer(service string, ratio float64, exporter Exporter) *Tracer {
+	var threshold uint64
+	switch {
+	case ratio >= 1:
+		threshold = math.MaxUint64
+	case ratio > 0:
+		threshold = uint64(ratio * math.MaxUint64)
+	}
+	return &Tracer{service: service, exporter: exporter, threshold: threshold}
+}
+
+func (t *Tracer) Service() string {
+	if t == nil {
+		return ""
+	}
+	return t.service
+}
+
+func (t *Tracer) shouldSample(id TraceID) bool {
+	if t.threshold == math.MaxUint64 {
+		return true
+	}
+	return binary.BigEndian.Uint64(id[8:]) < t.threshold
+}
+
+// Shutdown flushes buffered spans.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil || t.exporter == nil {
+		return nil
+	}
+	return t.exporter.Shutdown(ctx)
+}
+
+// Close implements io.Closer for use alongside other server resources.
+func (t *Tracer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return t.Shutdown(ctx)
+}
+
+type Span struct {
+	tracer *Tracer
+
+	mu         sync.Mutex
+	sc         SpanContext
+	parent     SpanID
+	name       string
+	kind       SpanKind
+	start, end time.Time
+	attrs      map[string]any
+	status     StatusCode
+	statusMsg  string
+	ended      bool
+}
+
+type spanKey struct{}
+
+// SpanFromContext returns the active span, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// SpanContextFromContext returns the context of the active span, if any.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+	return SpanContext{}
+}
+
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// Start begins a span that is a child of the span in ctx, or of remote when
+// ctx holds none and remote is valid.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, remote SpanContext) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	parent := remote
+	if local := SpanContextFromContext(ctx); local.IsValid() {
+		parent = local
+	}
+
+	span := &Span{
+		tracer: t,
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+		attrs:  make(map[string]any),
+	}
+	if parent.IsValid() {
+		span.sc = SpanContext{TraceID: parent.TraceID, Sampled: parent.Sampled, TraceState: parent.TraceState}
+		span.parent = parent.SpanID
+	} else {
+		span.sc.TraceID = newTraceID()
+		span.sc.Sampled = t.shouldSample(span.sc.TraceID)
+	}
+	span.sc.SpanID = newSpanID()
+	return ContextWithSpan(ctx, span), span
+}
+
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs[key] = value
+}
+
+func (s *Span) SetStatus(code StatusCode, msg string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.statusMsg = code, msg
+}
+
+// RecordError marks the span failed when err is non-nil.
+func (s *Span) RecordError(err error) {
+	if err != nil {
+		s.SetStatus(StatusError, err.Error())
+	}
+}
+
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+	if s.sc.Sampled && s.tracer.exporter != nil {
+		s.tracer.exporter.Export(s)
+	}
+}
@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"sync"
+	"testing"
+)
+
+// recorder is an Exporter that keeps what it is given.
+type recorder struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (r *recorder) Export(span *Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func (r *recorder) Shutdown(context.Context) error { return nil }
+
+func TestSamplingRatio(t *testing.T) {
+	// traceID puts x in the low half of the id, which sampling compares
+	// against the ratio scaled to the uint64 range.
+	traceID := func(x uint64) TraceID {
+		var id TraceID
+		id[0] = 1
+		binary.BigEndian.PutUint64(id[8:], x)
+		return id
+	}
+	quarter := uint64(math.MaxUint64 / 4)
+	tests := []struct {
+		ratio float64
+		id    TraceID
+		want  bool
+	}{
+		{0, traceID(0), false},
+		{-1, traceID(0), false},
+		{1, traceID(math.MaxUint64), true},
+		{2, traceID(math.MaxUint64), true},
+		{0.25, traceID(0), true},
+		{0.25, traceID(quarter - 1024), true},
+		{0.25, traceID(quarter + 1024), false},
+		{0.25, traceID(math.MaxUint64), false},
+	}
+	for _, tt := range tests {
+		tr := NewTracer("test", tt.ratio, nil)
+		if got := tr.shouldSample(tt.id); got != tt.want {
+			t.Errorf("ratio %v: shouldSample(%s) = %v, want %v", tt.ratio, tt.id, got, tt.want)
+		}
+	}
+
+	// Over random trace ids the sampled share tracks the ratio.
+	tr := NewTracer("test", 0.25, nil)
+	var sampled int
+	const n = 20000
+	for range n {
+		if _, span := tr.Start(context.Background(), "root", KindInternal, SpanContext{}); span.SpanContext().Sampled {
+			sampled++
+		}
+	}
+	if share := float64(sampled) / n; share < 0.22 || share > 0.28 {
+		t.Errorf("sampled %.3f of root spans, want about 0.25", share)
+	}
+}
+
+func TestChildSpans(t *testing.T) {
+	var rec recorder
+	// A ratio of 0 would drop every root span; children must still follow
+	// a sampled parent, and an unsampled one must win over a ratio of 1.
+	tests := []struct {
+		name    string
+		ratio   float64
+		sampled bool
+	}{
+		{"sampled parent", 0, true},
+		{"unsampled parent", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec.spans = nil
+			tr := NewTracer("test", tt.ratio, &rec)
+			remote := SpanContext{TraceID: TraceID{1}, SpanID: SpanID{2}, Sampled: tt.sampled, TraceState: "k=v", Remote: true}
+			ctx, server := tr.Start(context.Background(), "server", KindServer, remote)
+			_, child := tr.Start(ctx, "child", KindInternal, SpanContext{})
+			child.End()
+			server.End()
+
+			for _, s := range []*Span{server, child} {
+				sc := s.SpanContext()
+				if sc.TraceID != remote.TraceID || sc.Sampled != tt.sampled || sc.TraceState != "k=v" {
+					t.Errorf("%s: context = %+v, want the remote trace", s.name, sc)
+				}
+				if sc.Remote {
+					t.Errorf("%s: a local span is marked remote", s.name)
+				}
+			}
+			if server.parent != remote.SpanID || child.parent != server.SpanContext().SpanID {
+				t.Errorf("parents = %s, %s; want %s, %s", server.parent, child.parent, remote.SpanID, server.SpanContext().SpanID)
+			}
+			want := 0
+			if tt.sampled {
+				want = 2
+			}
+			if len(rec.spans) != want {
+				t.Errorf("exported %d spans, want %d", len(rec.spans), want)
+			}
+		})
+	}
+
+	// A nil tracer is a no-op.
+	var nilTracer *Tracer
+	ctx, span := nilTracer.Start(context.Background(), "x", KindInternal, SpanContext{})
+	span.SetAttribute("k", "v")
+	span.End()
+	if span != nil || SpanFromContext(ctx) != nil {
+		t.Error("a nil tracer started a span")
+	}
+}
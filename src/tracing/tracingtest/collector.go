@@ -0,0 +1,58 @@
+// Package tracingtest provides a fake OTLP/HTTP collector for tests.
+package tracingtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/tracing"
+)
+
+// Collector records every span posted to it.
+type Collector struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	spans []tracing.SpanData
+}
+
+// NewCollector starts a collector; point an OTLPExporter at Endpoint().
+func NewCollector() *Collector {
+	c := &Collector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(c.handle))
+	return c
+}
+
+func (c *Collector) Endpoint() string {
+	return c.URL + "/v1/traces"
+}
+
+func (c *Collector) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/v1/traces" {
+		http.NotFound(w, r)
+		return
+	}
+	var req tracing.ExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+	c.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+}
+
+// Spans returns a copy of everything received so far.
+func (c *Collector) Spans() []tracing.SpanData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]tracing.SpanData(nil), c.spans...)
+}
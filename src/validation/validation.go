@@ -0,0 +1,196 @@
+package validation
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/faishalshidqi/gin-introductory-proj/src/problem"
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/ar"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/id"
+	"github.com/go-playground/locales/it"
+	"github.com/go-playground/locales/ja"
+	"github.com/go-playground/locales/nl"
+	"github.com/go-playground/locales/pt"
+	"github.com/go-playground/locales/pt_BR"
+	"github.com/go-playground/locales/ru"
+	"github.com/go-playground/locales/tr"
+	"github.com/go-playground/locales/zh"
+	"github.com/go-playground/locales/zh_Hant_TW"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	ar_translations "github.com/go-playground/validator/v10/translations/ar"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
+	it_translations "github.com/go-playground/validator/v10/translations/it"
+	ja_translations "github.com/go-playground/validator/v10/translations/ja"
+	nl_translations "github.com/go-playground/validator/v10/translations/nl"
+	pt_translations "github.com/go-playground/validator/v10/translations/pt"
+	pt_BR_translations "github.com/go-playground/validator/v10/translations/pt_BR"
+	ru_translations "github.com/go-playground/validator/v10/translations/ru"
+	tr_translations "github.com/go-playground/validator/v10/translations/tr"
+	zh_translations "github.com/go-playground/validator/v10/translations/zh"
+	zh_tw_translations "github.com/go-playground/validator/v10/translations/zh_tw"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+type translation struct {
+	locale     locales.Translator
+	register   func(*validator.Validate, ut.Translator) error
+	personname string
+}
+
+var translations = []translation{
+	{en.New(), en_translations.RegisterDefaultTranslations, "{0} may only contain letters, spaces, hyphens and apostrophes"},
+	{ar.New(), ar_translations.RegisterDefaultTranslations, "يجب أن يحتوي {0} على أحرف ومسافات وشرطات وفواصل عليا فقط"},
+	{es.New(), es_translations.RegisterDefaultTranslations, "{0} solo puede contener letras, espacios, guiones y apóstrofos"},
+	{fr.New(), fr_translations.RegisterDefaultTranslations, "{0} ne peut contenir que des lettres, des espaces, des traits d'union et des apostrophes"},
+	{id.New(), id_translations.RegisterDefaultTranslations, "{0} hanya boleh berisi huruf, spasi, tanda hubung, dan apostrof"},
+	{it.New(), it_translations.RegisterDefaultTranslations, "{0} può contenere solo lettere, spazi, trattini e apostrofi"},
+	{ja.New(), ja_translations.RegisterDefaultTranslations, "{0}には文字、空白、ハイフン、アポストロフィのみ使用できます"},
+	{nl.New(), nl_translations.RegisterDefaultTranslations, "{0} mag alleen letters, spaties, koppeltekens en apostroffen bevatten"},
+	{pt.New(), pt_translations.RegisterDefaultTranslations, "{0} só pode conter letras, espaços, hífenes e apóstrofos"},
+	{pt_BR.New(), pt_BR_translations.RegisterDefaultTranslations, "{0} só pode conter letras, espaços, hífens e apóstrofos"},
+	{ru.New(), ru_translations.RegisterDefaultTranslations, "{0} может содержать только буквы, пробелы, дефисы и апострофы"},
+	{tr.New(), tr_translations.RegisterDefaultTranslations, "{0} yalnızca harf, boşluk, kısa çizgi ve kesme işareti içerebilir"},
+	{zh.New(), zh_translations.RegisterDefaultTranslations, "{0}只能包含字母、空格、连字符和撇号"},
+	{zh_Hant_TW.New(), zh_tw_translations.RegisterDefaultTranslations, "{0}只能包含字母、空格、連字號和撇號"},
+}
+
+var (
+	validate = newValidator()
+	uni      = ut.New(en.New(), localeList()...)
+)
+
+func localeList() []locales.Translator {
+	list := make([]locales.Translator, len(translations))
+	for i, t := range translations {
+		list[i] = t.locale
+	}
+	return list
+}
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	if err := v.RegisterValidation("personname", validatePersonName); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func init() {
+	for _, t := range translations {
+		trans, _ := uni.GetTranslator(t.locale.Locale())
+		if err := t.register(validate, trans); err != nil {
+			panic(err)
+		}
+		text := t.personname
+		err := validate.RegisterTranslation("personname", trans,
+			func(trans ut.Translator) error {
+				return trans.Add("personname", text, true)
+			},
+			func(trans ut.Translator, fe validator.FieldError) string {
+				msg, _ := trans.T("personname", fe.Field())
+				return msg
+			})
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// validatePersonName accepts letters with their combining marks, separated by
+// single spaces, hyphens or apostrophes (e.g. "Jean-Luc", "O'Brien").
+func validatePersonName(fl validator.FieldLevel) bool {
+	return IsPersonName(fl.Field().String())
+}
+
+func IsPersonName(name string) bool {
+	prevSeparator := true
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r):
+			prevSeparator = false
+		case unicode.Is(unicode.M, r):
+			if prevSeparator {
+				return false
+			}
+		case r == ' ' || r == '-' || r == '\'' || r == '’':
+			if prevSeparator {
+				return false
+			}
+			prevSeparator = true
+		default:
+			return false
+		}
+	}
+	return !prevSeparator
+}
+
+// NormalizeName trims surrounding whitespace and converts to NFC so that
+// visually identical names compare and count equally.
+func NormalizeName(name string) string {
+	return norm.NFC.String(strings.TrimSpace(name))
+}
+
+// Translator returns the validator translator closest to tag, falling back
+// to English.
+func Translator(tag language.Tag) ut.Translator {
+	candidates := []string{strings.ReplaceAll(tag.String(), "-", "_")}
+	if script, confidence := tag.Script(); confidence == language.Exact && script.String() == "Hant" {
+		candidates = append(candidates, "zh_Hant_TW")
+	}
+	base, _ := tag.Base()
+	candidates = append(candidates, base.String())
+	trans, _ := uni.FindTranslator(candidates...)
+	return trans
+}
+
+// Struct validates the `validate` tags of s and reports each failing field
+// with a message in the language of tag.
+func Struct(s any, tag language.Tag) []problem.InvalidParam {
+	return invalidParams(validate.Struct(s), tag)
+}
+
+func invalidParams(err error, tag language.Tag) []problem.InvalidParam {
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		if err != nil {
+			return []problem.InvalidParam{{Reason: err.Error()}}
+		}
+		return nil
+	}
+	trans := Translator(tag)
+	params := make([]problem.InvalidParam, 0, len(errs))
+	for _, fe := range errs {
+		params = append(params, problem.InvalidParam{Name: fe.Field(), Reason: fe.Translate(trans)})
+	}
+	return params
+}
+
+// Problem wraps params into a 400 validation problem.
+func Problem(params []problem.InvalidParam) *problem.Problem {
+	p := problem.New(http.StatusBadRequest, "the request contains invalid fields")
+	p.Type = problem.TypeValidation
+	p.Title = "Validation Failed"
+	p.InvalidParams = params
+	return p
+}